@@ -0,0 +1,137 @@
+package algorithm
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const defaultHashRingReplicas = 50
+
+// HashRing 一致性哈希环，每个节点在环上有多个虚拟节点，使键分布更均匀
+type HashRing struct {
+	// 每个节点的虚拟节点数
+	replicas int
+	hash     func(data []byte) uint32
+	// 虚拟节点，按哈希值从小到大排序，哈希值相同时按节点名排序
+	points []hashRingPoint
+	// 所有真实节点
+	members map[string]struct{}
+	lock    sync.RWMutex
+}
+
+// hashRingPoint 虚拟节点
+type hashRingPoint struct {
+	hash uint32
+	node string
+}
+
+// NewHashRing 初始化一致性哈希环，replicas 为每个节点的虚拟节点数，hash 为空时使用 crc32
+func NewHashRing(replicas int, hash func(data []byte) uint32) *HashRing {
+	if replicas <= 0 {
+		replicas = defaultHashRingReplicas
+	}
+
+	if hash == nil {
+		hash = crc32.ChecksumIEEE
+	}
+
+	return &HashRing{
+		replicas: replicas,
+		hash:     hash,
+		members:  make(map[string]struct{}),
+	}
+}
+
+// Add 添加节点，已存在的节点忽略
+func (r *HashRing) Add(nodes ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, node := range nodes {
+		if _, ok := r.members[node]; ok {
+			continue
+		}
+
+		r.members[node] = struct{}{}
+		for i := 0; i < r.replicas; i++ {
+			// 哈希冲突时两个虚拟节点都保留，节点名小的在前，与加入顺序无关，删除其中一个后另一个仍然生效
+			r.points = append(r.points, hashRingPoint{hash: r.hash([]byte(strconv.Itoa(i) + node)), node: node})
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash != r.points[j].hash {
+			return r.points[i].hash < r.points[j].hash
+		}
+		return r.points[i].node < r.points[j].node
+	})
+}
+
+// Remove 删除节点，只有该节点的键会迁移到其他节点
+func (r *HashRing) Remove(nodes ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	removed := false
+	for _, node := range nodes {
+		if _, ok := r.members[node]; !ok {
+			continue
+		}
+
+		delete(r.members, node)
+		removed = true
+	}
+
+	if !removed {
+		return
+	}
+
+	points := r.points[:0]
+	for _, p := range r.points {
+		if _, ok := r.members[p.node]; ok {
+			points = append(points, p)
+		}
+	}
+	r.points = points
+}
+
+// Get 获取键所属的节点，顺时针找到第一个虚拟节点，环为空时返回空字符串
+func (r *HashRing) Get(key string) string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	h := r.hash([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.points[i].node
+}
+
+// Nodes 获取所有节点，从小到大排序
+func (r *HashRing) Nodes() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	nodes := make([]string, 0, len(r.members))
+	for node := range r.members {
+		nodes = append(nodes, node)
+	}
+
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Len 节点数
+func (r *HashRing) Len() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.members)
+}
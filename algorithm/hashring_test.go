@@ -0,0 +1,95 @@
+package algorithm
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+func TestHashRing(t *testing.T) {
+	// 哈希值就是数字本身，方便计算
+	r := NewHashRing(3, func(data []byte) uint32 {
+		i, _ := strconv.Atoi(string(data))
+		return uint32(i)
+	})
+
+	if r.Get("1") != "" {
+		t.Fatalf("empty ring should return empty node")
+	}
+
+	// 虚拟节点 2, 12, 22, 4, 14, 24, 6, 16, 26
+	r.Add("6", "4", "2")
+	for key, node := range map[string]string{"2": "2", "11": "2", "23": "4", "27": "2"} {
+		if got := r.Get(key); got != node {
+			t.Fatalf("key %s should belong to %s, but %s", key, node, got)
+		}
+	}
+
+	// 虚拟节点 8, 18, 28
+	r.Add("8")
+	if got := r.Get("27"); got != "8" {
+		t.Fatalf("key 27 should belong to 8, but %s", got)
+	}
+
+	r.Remove("8")
+	if got := r.Get("27"); got != "2" {
+		t.Fatalf("key 27 should belong to 2 after remove 8, but %s", got)
+	}
+
+	if fmt.Sprint(r.Nodes()) != "[2 4 6]" || r.Len() != 3 {
+		t.Fatalf("nodes wrong: %v", r.Nodes())
+	}
+}
+
+func TestHashRingCollision(t *testing.T) {
+	// 所有虚拟节点哈希冲突
+	collide := func(data []byte) uint32 { return 7 }
+
+	r := NewHashRing(1, collide)
+	r.Add("a", "b")
+	if got := r.Get("key"); got != "a" {
+		t.Fatalf("collided node should be chosen by name, but %s", got)
+	}
+
+	r.Remove("a")
+	if got := r.Get("key"); got != "b" {
+		t.Fatalf("collided node should take over after remove, but %q", got)
+	}
+
+	// 与加入顺序无关
+	r = NewHashRing(1, collide)
+	r.Add("b", "a")
+	if got := r.Get("key"); got != "a" {
+		t.Fatalf("collided node should not depend on the add order, but %s", got)
+	}
+}
+
+func TestHashRingBalance(t *testing.T) {
+	r := NewHashRing(100, nil)
+	r.Add("a", "b", "c", "d")
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		counts[r.Get("key:"+strconv.Itoa(i))]++
+	}
+
+	for node, count := range counts {
+		if count < 1500 || count > 3500 {
+			t.Fatalf("node %s has %d keys, not balanced: %v", node, count, counts)
+		}
+	}
+
+	// 删除一个节点，只有它的键迁移
+	owners := make(map[string]string)
+	for i := 0; i < 10000; i++ {
+		key := "key:" + strconv.Itoa(i)
+		owners[key] = r.Get(key)
+	}
+
+	r.Remove("d")
+	for key, owner := range owners {
+		if owner != "d" && r.Get(key) != owner {
+			t.Fatalf("key %s should stay on %s, but %s", key, owner, r.Get(key))
+		}
+	}
+}
@@ -29,7 +29,9 @@ type Heap struct {
 	// 一个节点下标为 i，那么父亲节点的下标为 (i-1)/2
 	// 一个节点下标为 i，那么左儿子的下标为 2i+1，右儿子下标为 2i+2
 	array []*HeapValue
-	lock  sync.Mutex
+	// 数组的初始容量，缩容时不会小于该值
+	initCap int
+	lock    sync.Mutex
 }
 
 // NewMinHeap 初始化一个最小堆
@@ -38,8 +40,18 @@ func NewMinHeap(array []*HeapValue) *Heap {
 		return heapFast(array)
 	}
 
+	return NewMinHeapWithCap(heapInitCap)
+}
+
+// NewMinHeapWithCap 初始化一个指定初始容量的最小堆
+func NewMinHeapWithCap(initCap int) *Heap {
+	if initCap <= 0 {
+		initCap = heapInitCap
+	}
+
 	h := new(Heap)
-	h.array = make([]*HeapValue, 0, heapInitCap)
+	h.initCap = initCap
+	h.array = make([]*HeapValue, 0, initCap)
 	return h
 }
 
@@ -125,8 +137,27 @@ func (h *Heap) PopIndex(index int) *HeapValue {
 	x := h.array[h.size]  // 将最后一个元素的值先拿出来
 	h.array[h.size] = ret // 将移除的元素放在最后一个元素的位置上
 
-	// 对根节点进行向下翻转，大的值 x 一直下沉，维持最小堆的特征
+	// 移除的刚好是最后一个元素，不需要翻转
+	if index == h.size {
+		h.array = h.array[:h.size]
+		h.shrink()
+		return ret
+	}
+
+	// 移除的不是根节点时，最后一个元素 x 可能比新位置的父亲还小，需要先向上翻转
 	i := index
+	for i > 0 {
+		parent := (i - 1) / 2
+		if x.Value >= h.array[parent].Value {
+			break
+		}
+
+		h.array[i] = h.array[parent]
+		h.array[i].Index = i
+		i = parent
+	}
+
+	// 对节点进行向下翻转，大的值 x 一直下沉，维持最小堆的特征
 	for {
 		// a，b为下标 i 左右两个子节点的下标
 		a := 2*i + 1
@@ -161,13 +192,75 @@ func (h *Heap) PopIndex(index int) *HeapValue {
 
 	// 清除尾巴
 	h.array = h.array[:h.size]
+	h.shrink()
+	return ret
+}
+
+// Fix 元素的值改变后，将它上浮或下沉到正确的位置，O(log n)
+func (h *Heap) Fix(index int) {
+	if h == nil {
+		panic("h nil")
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if index < 0 || index >= h.size {
+		return
+	}
 
-	// 垃圾回收
-	if cap(h.array) > heapCleanCap && cap(h.array) > 2*h.size {
+	x := h.array[index]
+
+	// 值变小了，向上翻转
+	i := index
+	for i > 0 {
+		parent := (i - 1) / 2
+		if x.Value >= h.array[parent].Value {
+			break
+		}
+
+		h.array[i] = h.array[parent]
+		h.array[i].Index = i
+		i = parent
+	}
+
+	// 值变大了，向下翻转
+	for {
+		a := 2*i + 1
+		b := 2*i + 2
+		if a >= h.size {
+			break
+		}
+
+		if b < h.size && h.array[b].Value < h.array[a].Value {
+			a = b
+		}
+
+		if x.Value <= h.array[a].Value {
+			break
+		}
+
+		h.array[i] = h.array[a]
+		h.array[i].Index = i
+		i = a
+	}
+
+	h.array[i] = x
+	h.array[i].Index = i
+}
+
+// 垃圾回收，数组容量过大时缩容
+func (h *Heap) shrink() {
+	// 容量超过初始容量的 3 倍才缩容，避免反复分配
+	cleanCap := heapCleanCap
+	if 3*h.initCap > cleanCap {
+		cleanCap = 3 * h.initCap
+	}
+
+	if cap(h.array) > cleanCap && cap(h.array) > 2*h.size {
 		before := h.array
 
-		newCap := heapInitCap
-		if h.size > heapInitCap {
+		newCap := h.initCap
+		if h.size > h.initCap {
 			newCap = h.size
 		}
 
@@ -177,7 +270,6 @@ func (h *Heap) PopIndex(index int) *HeapValue {
 			copy(h.array, before[0:h.size])
 		}
 	}
-	return ret
 }
 
 // Min 最小堆获取最小值
@@ -239,6 +331,7 @@ func heapFast(array []*HeapValue) *Heap {
 	}
 
 	h := new(Heap)
+	h.initCap = heapInitCap
 	h.array = array
 	h.size = count
 	return h
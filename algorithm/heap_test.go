@@ -127,3 +127,51 @@ func TestNewHeap2(t *testing.T) {
 
 	fmt.Println(cap(h.array))
 }
+
+func TestHeapPopIndex(t *testing.T) {
+	h := NewMinHeap(nil)
+	for _, v := range []int64{1, 10, 2, 11, 12, 3, 4} {
+		h.Push(&HeapValue{Value: v})
+	}
+
+	// remove 11, the last one 4 must float up over 10
+	h.PopIndex(3)
+
+	var before int64
+	for h.Size() > 0 {
+		v := h.Pop()
+		if v.Value < before {
+			t.Fatalf("heap is broken, %d pop after %d", v.Value, before)
+		}
+		before = v.Value
+	}
+}
+
+func TestHeapFix(t *testing.T) {
+	h := NewMinHeap(nil)
+	values := make([]*HeapValue, 0, 100)
+	for i := 0; i < 100; i++ {
+		v := &HeapValue{Value: int64(i)}
+		values = append(values, v)
+		h.Push(v)
+	}
+
+	// 随机修改值后修复
+	for i, v := range values {
+		v.Value = int64((i * 37) % 101)
+		h.Fix(v.Index)
+	}
+
+	if h.Min().Value != 0 {
+		t.Fatalf("min should be 0, but %d", h.Min().Value)
+	}
+
+	var before int64
+	for h.Size() > 0 {
+		v := h.Pop()
+		if v.Value < before {
+			t.Fatalf("heap is broken, %d pop after %d", v.Value, before)
+		}
+		before = v.Value
+	}
+}
@@ -4,19 +4,19 @@ import (
 	"sync"
 )
 
-type linkQueue struct {
-	root *linkNode  // 链表起点
-	size int        // 队列的元素数量
-	lock sync.Mutex // 为了并发安全使用的锁
+type linkQueue[K any, V any] struct {
+	root *linkNode[K, V] // 链表起点
+	size int             // 队列的元素数量
+	lock sync.Mutex      // 为了并发安全使用的锁
 }
 
-type linkNode struct {
-	next  *linkNode
-	value bsTreeNode
+type linkNode[K any, V any] struct {
+	next  *linkNode[K, V]
+	value bsTreeNode[K, V]
 }
 
 // HasNext has next, queue size > 0
-func (queue *linkQueue) HasNext() bool {
+func (queue *linkQueue[K, V]) HasNext() bool {
 	if queue.size > 0 {
 		return true
 	}
@@ -24,7 +24,7 @@ func (queue *linkQueue) HasNext() bool {
 	return false
 }
 
-func (queue *linkQueue) Next() (key string, value interface{}) {
+func (queue *linkQueue[K, V]) Next() (key K, value V) {
 	// 不断出队列
 	element := queue.remove()
 
@@ -50,18 +50,18 @@ func (queue *linkQueue) Next() (key string, value interface{}) {
 }
 
 // 入队
-func (queue *linkQueue) add(v bsTreeNode) {
+func (queue *linkQueue[K, V]) add(v bsTreeNode[K, V]) {
 	queue.lock.Lock()
 	defer queue.lock.Unlock()
 
 	// 如果栈顶为空，那么增加节点
 	if queue.root == nil {
-		queue.root = new(linkNode)
+		queue.root = new(linkNode[K, V])
 		queue.root.value = v
 	} else {
 		// 否则新元素插入链表的末尾
 		// 新节点
-		newNode := new(linkNode)
+		newNode := new(linkNode[K, V])
 		newNode.value = v
 
 		// 一直遍历到链表尾部
@@ -79,7 +79,7 @@ func (queue *linkQueue) add(v bsTreeNode) {
 }
 
 // 出队
-func (queue *linkQueue) remove() bsTreeNode {
+func (queue *linkQueue[K, V]) remove() bsTreeNode[K, V] {
 	queue.lock.Lock()
 	defer queue.lock.Unlock()
 
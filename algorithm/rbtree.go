@@ -18,25 +18,25 @@ func ReflectError(v interface{}) error {
 
 // red-black tree, short call rbt
 // refer Java TreeMap
-type rbTree struct {
-	c          comparator // tree key compare
-	root       *rbTNode   // tree root node
-	len        int64      // tree key pairs num
-	sync.Mutex            // lock for concurrent safe
+type rbTree[K any, V any] struct {
+	c          func(key1, key2 K) int64 // tree key compare
+	root       *rbTNode[K, V]           // tree root node
+	len        int64                    // tree key pairs num
+	sync.Mutex                          // lock for concurrent safe
 }
 
 // rbt node
 // all field is lowercase to keep black box
-type rbTNode struct {
-	k      string      // key
-	v      interface{} // value
-	left   *rbTNode    // left tree
-	right  *rbTNode    // right tree
-	parent *rbTNode    // node's parent
-	color  bool        // color of parent point to this node
+type rbTNode[K any, V any] struct {
+	k      K              // key
+	v      V              // value
+	left   *rbTNode[K, V] // left tree
+	right  *rbTNode[K, V] // right tree
+	parent *rbTNode[K, V] // node's parent
+	color  bool           // color of parent point to this node
 }
 
-func (node *rbTNode) height() int64 {
+func (node *rbTNode[K, V]) height() int64 {
 	if node == nil {
 		return 0
 	}
@@ -50,12 +50,12 @@ func (node *rbTNode) height() int64 {
 	}
 }
 
-func (tree *rbTree) Height() int64 {
+func (tree *rbTree[K, V]) Height() int64 {
 	return tree.root.height()
 }
 
 // is rbt node is red
-func isRed(node *rbTNode) bool {
+func isRed[K any, V any](node *rbTNode[K, V]) bool {
 	if node == nil {
 		return false
 	}
@@ -63,7 +63,7 @@ func isRed(node *rbTNode) bool {
 }
 
 // 返回节点的父亲节点
-func parentOf(node *rbTNode) *rbTNode {
+func parentOf[K any, V any](node *rbTNode[K, V]) *rbTNode[K, V] {
 	if node == nil {
 		return nil
 	}
@@ -72,7 +72,7 @@ func parentOf(node *rbTNode) *rbTNode {
 }
 
 // 返回节点的左子节点
-func leftOf(node *rbTNode) *rbTNode {
+func leftOf[K any, V any](node *rbTNode[K, V]) *rbTNode[K, V] {
 	if node == nil {
 		return nil
 	}
@@ -81,7 +81,7 @@ func leftOf(node *rbTNode) *rbTNode {
 }
 
 // 返回节点的右子节点
-func rightOf(node *rbTNode) *rbTNode {
+func rightOf[K any, V any](node *rbTNode[K, V]) *rbTNode[K, V] {
 	if node == nil {
 		return nil
 	}
@@ -90,24 +90,22 @@ func rightOf(node *rbTNode) *rbTNode {
 }
 
 // 设置节点颜色
-func setColor(node *rbTNode, color bool) {
+func setColor[K any, V any](node *rbTNode[K, V], color bool) {
 	if node != nil {
 		node.color = color
 	}
 }
 
-func (tree *rbTree) SetComparator(c comparator) TreeMap {
+func (tree *rbTree[K, V]) setComparator(c func(key1, key2 K) int64) {
 	tree.Lock()
 	defer tree.Unlock()
 	if tree.len == 0 {
 		tree.c = c
 	}
-
-	return tree
 }
 
 // 对某节点左旋转
-func (tree *rbTree) rotateLeft(h *rbTNode) {
+func (tree *rbTree[K, V]) rotateLeft(h *rbTNode[K, V]) {
 	if h != nil {
 
 		// 看图理解
@@ -132,7 +130,7 @@ func (tree *rbTree) rotateLeft(h *rbTNode) {
 }
 
 // 对某节点右旋转
-func (tree *rbTree) rotateRight(h *rbTNode) {
+func (tree *rbTree[K, V]) rotateRight(h *rbTNode[K, V]) {
 	if h != nil {
 
 		// 看图理解
@@ -157,7 +155,7 @@ func (tree *rbTree) rotateRight(h *rbTNode) {
 }
 
 // Put 普通红黑树添加元素
-func (tree *rbTree) Put(key string, value interface{}) {
+func (tree *rbTree[K, V]) Put(key K, value V) {
 	// add lock
 	tree.Lock()
 	defer tree.Unlock()
@@ -166,7 +164,7 @@ func (tree *rbTree) Put(key string, value interface{}) {
 	// 根节点为空
 	if tree.root == nil {
 		// 根节点都是黑色
-		tree.root = &rbTNode{
+		tree.root = &rbTNode[K, V]{
 			k:     key,
 			v:     value,
 			color: bLACK,
@@ -179,7 +177,7 @@ func (tree *rbTree) Put(key string, value interface{}) {
 	t := tree.root
 
 	// 插入元素后，插入元素的父亲节点
-	var parent *rbTNode
+	var parent *rbTNode[K, V]
 
 	// 辅助变量，为了知道元素最后要插到左边还是右边
 	var cmp int64 = 0
@@ -207,7 +205,7 @@ func (tree *rbTree) Put(key string, value interface{}) {
 	}
 
 	// 新节点，它要插入到 parent下面
-	newNode := &rbTNode{
+	newNode := &rbTNode[K, V]{
 		k:      key,
 		v:      value,
 		parent: parent,
@@ -229,7 +227,7 @@ func (tree *rbTree) Put(key string, value interface{}) {
 
 // 调整新插入的节点，自底而上
 // 可以看图理解
-func (tree *rbTree) fixAfterInsertion(node *rbTNode) {
+func (tree *rbTree[K, V]) fixAfterInsertion(node *rbTNode[K, V]) {
 	// 插入的新节点一定要是红色
 	node.color = rED
 
@@ -291,7 +289,7 @@ func (tree *rbTree) fixAfterInsertion(node *rbTNode) {
 }
 
 // Delete 普通红黑树删除元素
-func (tree *rbTree) Delete(key string) {
+func (tree *rbTree[K, V]) Delete(key K) {
 	tree.Lock()
 	defer tree.Unlock()
 
@@ -315,7 +313,7 @@ func (tree *rbTree) Delete(key string) {
 
 // 删除节点核心函数
 // 找最小后驱节点来补位，删除内部节点转为删除叶子节点
-func (tree *rbTree) delete(node *rbTNode) {
+func (tree *rbTree[K, V]) delete(node *rbTNode[K, V]) {
 	// 如果左右子树都存在，那么从右子树的左边一直找一直找，就找能到最小后驱节点
 	if node.left != nil && node.right != nil {
 		s := node.right
@@ -405,7 +403,7 @@ func (tree *rbTree) delete(node *rbTNode) {
 
 // 调整删除的叶子节点，自底向上
 // 可以看图理解
-func (tree *rbTree) fixAfterDeletion(node *rbTNode) {
+func (tree *rbTree[K, V]) fixAfterDeletion(node *rbTNode[K, V]) {
 	// 如果不是递归到根节点，且节点是黑节点，那么继续递归
 	for tree.root != node && !isRed(node) {
 		// 要删除的节点在父亲左边，对应图例1，2
@@ -486,7 +484,7 @@ func (tree *rbTree) fixAfterDeletion(node *rbTNode) {
 }
 
 // MinKey find min key pairs
-func (tree *rbTree) MinKey() (key string, value interface{}, exist bool) {
+func (tree *rbTree[K, V]) MinKey() (key K, value V, exist bool) {
 	// add lock
 	tree.Lock()
 	defer tree.Unlock()
@@ -499,7 +497,7 @@ func (tree *rbTree) MinKey() (key string, value interface{}, exist bool) {
 	return node.k, node.v, true
 }
 
-func (node *rbTNode) minNode() *rbTNode {
+func (node *rbTNode[K, V]) minNode() *rbTNode[K, V] {
 	// 左子树为空，表面已经是最左的节点了，该值就是最小值
 	if node.left == nil {
 		return node
@@ -510,7 +508,7 @@ func (node *rbTNode) minNode() *rbTNode {
 }
 
 // MaxKey find max key pairs
-func (tree *rbTree) MaxKey() (key string, value interface{}, exist bool) {
+func (tree *rbTree[K, V]) MaxKey() (key K, value V, exist bool) {
 	// add lock
 	tree.Lock()
 	defer tree.Unlock()
@@ -523,7 +521,7 @@ func (tree *rbTree) MaxKey() (key string, value interface{}, exist bool) {
 	return node.k, node.v, true
 }
 
-func (node *rbTNode) maxNode() *rbTNode {
+func (node *rbTNode[K, V]) maxNode() *rbTNode[K, V] {
 	// 右子树为空，表面已经是最右的节点了，该值就是最大值
 	if node.right == nil {
 		return node
@@ -534,7 +532,7 @@ func (node *rbTNode) maxNode() *rbTNode {
 }
 
 // Get 查找指定节点
-func (tree *rbTree) Get(key string) (value interface{}, exist bool) {
+func (tree *rbTree[K, V]) Get(key K) (value V, exist bool) {
 	tree.Lock()
 	defer tree.Unlock()
 	if tree.root == nil {
@@ -550,7 +548,7 @@ func (tree *rbTree) Get(key string) (value interface{}, exist bool) {
 }
 
 // Contains 查找指定节点
-func (tree *rbTree) Contains(key string) (exist bool) {
+func (tree *rbTree[K, V]) Contains(key K) (exist bool) {
 	tree.Lock()
 	defer tree.Unlock()
 	if tree.root == nil {
@@ -564,18 +562,18 @@ func (tree *rbTree) Contains(key string) (exist bool) {
 	}
 }
 
-func (tree *rbTree) Len() int64 {
+func (tree *rbTree[K, V]) Len() int64 {
 	return tree.len
 }
 
-func (tree *rbTree) GetInt(key string) (value int, exist bool, err error) {
-	var v interface{}
+func (tree *rbTree[K, V]) GetInt(key K) (value int, exist bool, err error) {
+	var v V
 	v, exist = tree.Get(key)
 	if !exist {
 		return
 	}
 
-	value, ok := v.(int)
+	value, ok := any(v).(int)
 	if !ok {
 		err = ReflectError(v)
 		return
@@ -584,14 +582,14 @@ func (tree *rbTree) GetInt(key string) (value int, exist bool, err error) {
 	return value, true, nil
 }
 
-func (tree *rbTree) GetInt64(key string) (value int64, exist bool, err error) {
-	var v interface{}
+func (tree *rbTree[K, V]) GetInt64(key K) (value int64, exist bool, err error) {
+	var v V
 	v, exist = tree.Get(key)
 	if !exist {
 		return
 	}
 
-	value, ok := v.(int64)
+	value, ok := any(v).(int64)
 	if !ok {
 		err = ReflectError(v)
 		return
@@ -600,14 +598,14 @@ func (tree *rbTree) GetInt64(key string) (value int64, exist bool, err error) {
 	return value, true, nil
 }
 
-func (tree *rbTree) GetString(key string) (value string, exist bool, err error) {
-	var v interface{}
+func (tree *rbTree[K, V]) GetString(key K) (value string, exist bool, err error) {
+	var v V
 	v, exist = tree.Get(key)
 	if !exist {
 		return
 	}
 
-	value, ok := v.(string)
+	value, ok := any(v).(string)
 	if !ok {
 		err = ReflectError(v)
 		return
@@ -616,14 +614,14 @@ func (tree *rbTree) GetString(key string) (value string, exist bool, err error)
 	return value, true, nil
 }
 
-func (tree *rbTree) GetFloat64(key string) (value float64, exist bool, err error) {
-	var v interface{}
+func (tree *rbTree[K, V]) GetFloat64(key K) (value float64, exist bool, err error) {
+	var v V
 	v, exist = tree.Get(key)
 	if !exist {
 		return
 	}
 
-	value, ok := v.(float64)
+	value, ok := any(v).(float64)
 	if !ok {
 		err = ReflectError(v)
 		return
@@ -632,14 +630,14 @@ func (tree *rbTree) GetFloat64(key string) (value float64, exist bool, err error
 	return value, true, nil
 }
 
-func (tree *rbTree) GetBytes(key string) (value []byte, exist bool, err error) {
-	var v interface{}
+func (tree *rbTree[K, V]) GetBytes(key K) (value []byte, exist bool, err error) {
+	var v V
 	v, exist = tree.Get(key)
 	if !exist {
 		return
 	}
 
-	value, ok := v.([]byte)
+	value, ok := any(v).([]byte)
 	if !ok {
 		err = ReflectError(v)
 		return
@@ -649,7 +647,7 @@ func (tree *rbTree) GetBytes(key string) (value []byte, exist bool, err error) {
 }
 
 // find key in tree
-func (tree *rbTree) find(key string) *rbTNode {
+func (tree *rbTree[K, V]) find(key K) *rbTNode[K, V] {
 	node := tree.root
 	for {
 		cmp := tree.c(key, node.k)
@@ -667,17 +665,71 @@ func (tree *rbTree) find(key string) *rbTNode {
 	}
 }
 
+// Range 中序遍历，f 返回 false 时停止
+// f 在锁内调用，不能再操作该树
+func (tree *rbTree[K, V]) Range(f func(key K, value V) bool) {
+	tree.Lock()
+	defer tree.Unlock()
+	tree.root.rangeMidOrder(f)
+}
+
+func (node *rbTNode[K, V]) rangeMidOrder(f func(key K, value V) bool) bool {
+	if node == nil {
+		return true
+	}
+
+	if !node.left.rangeMidOrder(f) {
+		return false
+	}
+
+	if !f(node.k, node.v) {
+		return false
+	}
+
+	return node.right.rangeMidOrder(f)
+}
+
+// RangeFrom 从第一个大于等于 start 的键开始中序遍历，f 返回 false 时停止
+// 小于 start 的子树直接剪掉，只走相关的子树
+func (tree *rbTree[K, V]) RangeFrom(start K, f func(key K, value V) bool) {
+	tree.Lock()
+	defer tree.Unlock()
+	tree.root.rangeFrom(start, tree.c, f)
+}
+
+func (node *rbTNode[K, V]) rangeFrom(start K, c func(key1, key2 K) int64, f func(key K, value V) bool) bool {
+	if node == nil {
+		return true
+	}
+
+	// 当前节点小于 start，左子树也都小于 start
+	if c(node.k, start) < 0 {
+		return node.right.rangeFrom(start, c, f)
+	}
+
+	if !node.left.rangeFrom(start, c, f) {
+		return false
+	}
+
+	if !f(node.k, node.v) {
+		return false
+	}
+
+	// 右子树都大于 start
+	return node.right.rangeMidOrder(f)
+}
+
 // KeySortedList 中序遍历
 // midOrder get key list
-func (tree *rbTree) KeySortedList() []string {
+func (tree *rbTree[K, V]) KeySortedList() []K {
 	// add lock
 	tree.Lock()
 	defer tree.Unlock()
-	keyList := make([]string, 0, tree.len)
+	keyList := make([]K, 0, tree.len)
 	return tree.root.midOrder(keyList)
 }
 
-func (node *rbTNode) midOrder(keyList []string) []string {
+func (node *rbTNode[K, V]) midOrder(keyList []K) []K {
 	if node == nil {
 		return keyList
 	}
@@ -694,7 +746,7 @@ func (node *rbTNode) midOrder(keyList []string) []string {
 }
 
 // Check 验证是不是棵红黑树
-func (tree *rbTree) Check() bool {
+func (tree *rbTree[K, V]) Check() bool {
 	if tree == nil || tree.root == nil {
 		return true
 	}
@@ -730,7 +782,7 @@ func (tree *rbTree) Check() bool {
 }
 
 // 节点所在的子树是否是一棵二分查找树
-func (node *rbTNode) isBST(c comparator) bool {
+func (node *rbTNode[K, V]) isBST(c func(key1, key2 K) int64) bool {
 	if node == nil {
 		return true
 	}
@@ -769,7 +821,7 @@ func (node *rbTNode) isBST(c comparator) bool {
 }
 
 // 节点所在的子树是否遵循2-3-4树
-func (node *rbTNode) is234() bool {
+func (node *rbTNode[K, V]) is234() bool {
 	if node == nil {
 		return true
 	}
@@ -799,7 +851,7 @@ func (node *rbTNode) is234() bool {
 }
 
 // 节点所在的子树是否平衡，是否有 blackNum 个黑链接
-func (node *rbTNode) isBalanced(blackNum int) bool {
+func (node *rbTNode[K, V]) isBalanced(blackNum int) bool {
 	if node == nil {
 		return blackNum == 0
 	}
@@ -822,14 +874,14 @@ func (node *rbTNode) isBalanced(blackNum int) bool {
 }
 
 // iterator help struct
-type bsTreeNode interface {
-	leftOf() bsTreeNode
-	rightOf() bsTreeNode
-	values() (key string, value interface{})
+type bsTreeNode[K any, V any] interface {
+	leftOf() bsTreeNode[K, V]
+	rightOf() bsTreeNode[K, V]
+	values() (key K, value V)
 }
 
 // 返回节点的左子节点
-func (node *rbTNode) leftOf() bsTreeNode {
+func (node *rbTNode[K, V]) leftOf() bsTreeNode[K, V] {
 	if node.left == nil {
 		return nil
 	}
@@ -838,7 +890,7 @@ func (node *rbTNode) leftOf() bsTreeNode {
 }
 
 // 返回节点的右子节点
-func (node *rbTNode) rightOf() bsTreeNode {
+func (node *rbTNode[K, V]) rightOf() bsTreeNode[K, V] {
 	if node.right == nil {
 		return nil
 	}
@@ -847,19 +899,19 @@ func (node *rbTNode) rightOf() bsTreeNode {
 }
 
 // not check node nil, may be panic, user should deal by oneself
-func (node *rbTNode) values() (key string, value interface{}) {
+func (node *rbTNode[K, V]) values() (key K, value V) {
 	return node.k, node.v
 }
 
-func (tree *rbTree) KeyList() []string {
+func (tree *rbTree[K, V]) KeyList() []K {
 	tree.Lock()
 	defer tree.Unlock()
 
 	if tree.root == nil {
-		return []string{}
+		return []K{}
 	}
 
-	keyList := make([]string, 0, tree.len)
+	keyList := make([]K, 0, tree.len)
 	iterator := tree.Iterator()
 	for iterator.HasNext() {
 		k, _ := iterator.Next()
@@ -870,8 +922,8 @@ func (tree *rbTree) KeyList() []string {
 
 }
 
-func (tree *rbTree) Iterator() TreeMapIterator {
-	q := new(linkQueue)
+func (tree *rbTree[K, V]) Iterator() *linkQueue[K, V] {
+	q := new(linkQueue[K, V])
 	if tree.root != nil {
 		q.add(tree.root)
 	}
@@ -1,6 +1,9 @@
 package algorithm
 
-import "strings"
+import (
+	"cmp"
+	"strings"
+)
 
 type comparator func(key1, key2 string) int64
 
@@ -20,6 +23,7 @@ type TreeMap interface {
 	Len() int64                                                   // map key pairs num
 	KeyList() []string                                            // map key out to list from top to bottom which is layer order
 	KeySortedList() []string                                      // map key out to list sorted
+	RangeFrom(start string, f func(string, interface{}) bool)     // range key pairs sorted from the first key >= start, stop when f return false
 	Iterator() TreeMapIterator                                    // map iterator, iterator from top to bottom which is layer order
 	MaxKey() (key string, value interface{}, exist bool)          // find max key pairs
 	MinKey() (key string, value interface{}, exist bool)          // find min key pairs
@@ -37,11 +41,59 @@ type TreeMapIterator interface {
 
 // NewTreeMap default map is rbt implement
 func NewTreeMap() TreeMap {
-	t := new(rbTree)
+	t := new(treeMap)
+	t.rbTree = new(rbTree[string, interface{}])
 	t.c = comparatorDefault
 	return t
 }
 
+// treeMap string key rbt
+type treeMap struct {
+	*rbTree[string, interface{}]
+}
+
+func (t *treeMap) SetComparator(c comparator) TreeMap {
+	t.setComparator(c)
+	return t
+}
+
+func (t *treeMap) Iterator() TreeMapIterator {
+	return t.rbTree.Iterator()
+}
+
+// Ordered key type can be compared by < and >
+type Ordered = cmp.Ordered
+
+// OrderedMap generic version of TreeMap, keys are kept in order by the compare func
+// design to be concurrent safe
+type OrderedMap[K any, V any] interface {
+	Put(key K, value V)                   // put key pairs
+	Delete(key K)                         // delete a key
+	Get(key K) (value V, exist bool)      // get value from key
+	Contains(key K) (exist bool)          // map contains key?
+	Len() int64                           // map key pairs num
+	KeySortedList() []K                   // map key out to list sorted
+	Range(f func(key K, value V) bool)    // range key pairs sorted, stop when f return false
+	RangeFrom(start K, f func(K, V) bool) // range key pairs sorted from the first key >= start, stop when f return false
+	MaxKey() (key K, value V, exist bool) // find max key pairs
+	MinKey() (key K, value V, exist bool) // find min key pairs
+}
+
+// NewOrderedMap new a rbt implement map with ordered key
+func NewOrderedMap[K Ordered, V any]() OrderedMap[K, V] {
+	return NewOrderedMapFunc[K, V](cmp.Compare[K])
+}
+
+// NewOrderedMapFunc new a rbt implement map, keys are compared by the compare func,
+// which return a negative number when key1 < key2, a positive number when key1 > key2, zero when equal
+func NewOrderedMapFunc[K any, V any](compare func(key1, key2 K) int) OrderedMap[K, V] {
+	t := new(rbTree[K, V])
+	t.c = func(key1, key2 K) int64 {
+		return int64(compare(key1, key2))
+	}
+	return t
+}
+
 // compare two key
 func comparatorDefault(key1, key2 string) int64 {
 	return int64(strings.Compare(key1, key2))
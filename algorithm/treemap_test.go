@@ -175,3 +175,65 @@ func TestNew(t *testing.T) {
 		fmt.Println("is a rb tree,len:", m.Len())
 	}
 }
+
+func TestNewOrderedMap(t *testing.T) {
+	m := NewOrderedMap[int, string]()
+	for _, k := range []int{5, 3, 9, 1, 7} {
+		m.Put(k, fmt.Sprintf("v%d", k))
+	}
+	m.Delete(9)
+
+	var keys []int
+	m.Range(func(key int, value string) bool {
+		keys = append(keys, key)
+		return key < 5
+	})
+
+	if fmt.Sprint(keys) != "[1 3 5]" {
+		t.Fatalf("range should stop at 5, but %v", keys)
+	}
+
+	if v, exist := m.Get(7); !exist || v != "v7" {
+		t.Fatalf("7 should be v7, but %s", v)
+	}
+
+	if k, _, _ := m.MaxKey(); k != 7 {
+		t.Fatalf("max key should be 7, but %d", k)
+	}
+
+	// reverse order
+	r := NewOrderedMapFunc[int, string](func(key1, key2 int) int {
+		return key2 - key1
+	})
+	r.Put(1, "a")
+	r.Put(2, "b")
+	if fmt.Sprint(r.KeySortedList()) != "[2 1]" {
+		t.Fatalf("key sorted list should be [2 1], but %v", r.KeySortedList())
+	}
+}
+
+func TestRangeFrom(t *testing.T) {
+	m := NewOrderedMap[int, int]()
+	for i := 0; i < 100; i += 2 {
+		m.Put(i, i)
+	}
+
+	for _, start := range []int{-1, 0, 31, 32, 98, 99} {
+		var keys []int
+		m.RangeFrom(start, func(key int, value int) bool {
+			keys = append(keys, key)
+			return len(keys) < 3
+		})
+
+		var want []int
+		for i := 0; i < 100 && len(want) < 3; i += 2 {
+			if i >= start {
+				want = append(want, i)
+			}
+		}
+
+		if fmt.Sprint(keys) != fmt.Sprint(want) {
+			t.Fatalf("range from %d should be %v, but %v", start, want, keys)
+		}
+	}
+}
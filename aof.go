@@ -0,0 +1,435 @@
+package gocache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/hunterhug/gocache/algorithm"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// FsyncPolicy how often the append only file is synced to disk
+type FsyncPolicy int
+
+const (
+	// FsyncEverySecond sync the file every second, at most one second of writes is lost when the machine crash
+	FsyncEverySecond FsyncPolicy = iota
+	// FsyncAlways sync the file after every write, slow but safe
+	FsyncAlways
+	// FsyncNever leave it to the operating system
+	FsyncNever
+)
+
+// aof format:
+//
+//	magic "GOCACHEAOF" | version byte | records...
+//	record: op byte | expireUnixNanosecondDateTime int64 | key length uvarint | key | value length uvarint | value | crc32 of the record
+const (
+	aofMagic   = "GOCACHEAOF"
+	aofVersion = 1
+
+	aofOpSetByte      byte = 1
+	aofOpSetInterface byte = 2
+	aofOpDelete       byte = 3
+
+	defaultAOFRewriteMinSize = 64 << 20
+)
+
+var ErrAOFFormat = errors.New("gocache: aof format invalid")
+
+// NewWithAOF new a cache which append every write to the file at path,
+// the file is replayed first, the expired keys are dropped,
+// and it is rewritten from the live keys in background when it grows twice as big as the last rewrite
+func NewWithAOF(path string, policy FsyncPolicy, opts ...Option) (Cache, error) {
+	c := newCache(newOptions(opts...))
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := c.replayAOF(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	c.aof = &aof{
+		path:            path,
+		file:            f,
+		policy:          policy,
+		codec:           c.opts.codec,
+		size:            size,
+		rewriteBaseSize: size,
+		rewriteMinSize:  c.opts.aofRewriteMinSize,
+		closeChan:       make(chan struct{}),
+		onError:         c.opts.aofErrorFunc,
+	}
+
+	if policy == FsyncEverySecond {
+		go c.aof.loopSync()
+	}
+
+	if c.opts.janitor {
+		go c.loopCleanExpireItem()
+	}
+	return c, nil
+}
+
+type aof struct {
+	locker sync.Mutex
+	path   string
+	file   *os.File
+	policy FsyncPolicy
+	codec  Codec
+	size   int64
+	// rewrite when the size is twice as big as the size after last rewrite and bigger than rewriteMinSize
+	rewriteBaseSize int64
+	rewriteMinSize  int64
+	rewriting       bool
+	// records appended during rewriting, they are appended to the new file when the rewrite finish
+	rewriteBuf bytes.Buffer
+	dirty      bool
+	closed     bool
+	closeChan  chan struct{}
+	onError    func(err error)
+}
+
+func encodeAOFRecord(buf []byte, op byte, key string, value []byte, expireUnixNanosecondDateTime int64) []byte {
+	start := len(buf)
+	buf = append(buf, op)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(expireUnixNanosecondDateTime))
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	buf = append(buf, key...)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	buf = append(buf, value...)
+	return binary.BigEndian.AppendUint32(buf, crc32.Checksum(buf[start:], crcTable))
+}
+
+// replayAOF apply the records of the file, write the header if the file is empty,
+// the broken tail which is written partly when crash will be truncated, return the valid size
+func (c *cache) replayAOF(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	header := make([]byte, len(aofMagic)+1)
+	n, err := io.ReadFull(r, header)
+	if err == io.EOF {
+		header = append([]byte(aofMagic), aofVersion)
+		if _, err := f.Write(header); err != nil {
+			return 0, err
+		}
+		return int64(len(header)), f.Sync()
+	}
+
+	if err != nil || string(header[:len(aofMagic)]) != aofMagic || header[len(aofMagic)] != aofVersion {
+		return 0, ErrAOFFormat
+	}
+
+	offset := int64(n)
+	now := time.Now().UnixNano()
+	c.locker.Lock()
+	defer c.unlock()
+	for {
+		op, key, value, expire, n, err := readAOFRecord(r)
+		if err != nil {
+			break
+		}
+		offset += n
+
+		treeMapValue, exist := c.treeMap.Get(key)
+		if op == aofOpDelete || expire <= now {
+			if exist {
+				c.remove(treeMapValue.(*algorithm.HeapValue), EvictReasonDeleted)
+			}
+			continue
+		}
+
+		item := cacheItem{
+			RawByte: value,
+			cost:    int64(len(key) + len(value)),
+		}
+		if op == aofOpSetInterface {
+			raw, err := c.opts.codec.Unmarshal(value)
+			if err != nil {
+				return 0, err
+			}
+
+			item = cacheItem{
+				Raw:         raw,
+				isInterface: true,
+				cost:        c.interfaceCost(key, raw),
+			}
+		}
+
+		c.put(key, item, expire)
+	}
+
+	// the replayed records are not counted
+	c.stats.reset()
+
+	if err := f.Truncate(offset); err != nil {
+		return 0, err
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return offset, nil
+}
+
+func readAOFRecord(r *bufio.Reader) (op byte, key string, value []byte, expireUnixNanosecondDateTime int64, n int64, err error) {
+	op, err = r.ReadByte()
+	if err != nil {
+		return
+	}
+
+	if op != aofOpSetByte && op != aofOpSetInterface && op != aofOpDelete {
+		err = ErrAOFFormat
+		return
+	}
+
+	expire := make([]byte, 8)
+	if _, err = io.ReadFull(r, expire); err != nil {
+		return
+	}
+	expireUnixNanosecondDateTime = int64(binary.BigEndian.Uint64(expire))
+
+	fields := make([][]byte, 2)
+	for i := range fields {
+		var length uint64
+		length, err = binary.ReadUvarint(r)
+		if err != nil {
+			return
+		}
+
+		if length > snapshotMaxFieldLength {
+			err = ErrAOFFormat
+			return
+		}
+
+		fields[i] = make([]byte, length)
+		if _, err = io.ReadFull(r, fields[i]); err != nil {
+			return
+		}
+	}
+
+	checksum := make([]byte, 4)
+	if _, err = io.ReadFull(r, checksum); err != nil {
+		return
+	}
+
+	record := encodeAOFRecord(nil, op, string(fields[0]), fields[1], expireUnixNanosecondDateTime)
+	if !bytes.Equal(record[len(record)-4:], checksum) {
+		err = ErrAOFFormat
+		return
+	}
+
+	return op, string(fields[0]), fields[1], expireUnixNanosecondDateTime, int64(len(record)), nil
+}
+
+// appendAOFSet log the set, must hold the cache lock,
+// the value can not be marshaled is logged as a delete, so the overwritten value does not come back after replay
+func (c *cache) appendAOFSet(key string, item *cacheItem) {
+	if c.aof == nil {
+		return
+	}
+
+	op, value := aofOpSetByte, item.RawByte
+	if item.isInterface {
+		v, err := c.aof.codec.Marshal(item.Raw)
+		if err != nil {
+			c.aofFail(fmt.Errorf("gocache: aof can not marshal the value of key %s: %w", key, err))
+			c.appendAOFDelete(key)
+			return
+		}
+		op, value = aofOpSetInterface, v
+	}
+
+	c.aofFail(c.aof.append(encodeAOFRecord(nil, op, key, value, item.expireUnixNanosecondDateTime)))
+	c.maybeRewriteAOF()
+}
+
+// appendAOFDelete log the delete, must hold the cache lock
+func (c *cache) appendAOFDelete(key string) {
+	if c.aof == nil {
+		return
+	}
+
+	c.aofFail(c.aof.append(encodeAOFRecord(nil, aofOpDelete, key, nil, 0)))
+	c.maybeRewriteAOF()
+}
+
+// aofFail keep the error to report after unlock, must hold the cache lock
+func (c *cache) aofFail(err error) {
+	if err != nil && c.aof.onError != nil {
+		c.aofErrors = append(c.aofErrors, err)
+	}
+}
+
+func (a *aof) append(record []byte) error {
+	a.locker.Lock()
+	defer a.locker.Unlock()
+	if a.closed {
+		return nil
+	}
+
+	if _, err := a.file.Write(record); err != nil {
+		return err
+	}
+
+	a.size += int64(len(record))
+	if a.rewriting {
+		a.rewriteBuf.Write(record)
+	}
+
+	if a.policy == FsyncAlways {
+		return a.file.Sync()
+	}
+
+	a.dirty = true
+	return nil
+}
+
+func (a *aof) loopSync() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.locker.Lock()
+			if a.dirty {
+				a.file.Sync()
+				a.dirty = false
+			}
+			a.locker.Unlock()
+		case <-a.closeChan:
+			return
+		}
+	}
+}
+
+func (a *aof) close() {
+	a.locker.Lock()
+	defer a.locker.Unlock()
+	if a.closed {
+		return
+	}
+
+	a.closed = true
+	close(a.closeChan)
+	a.file.Sync()
+	a.file.Close()
+}
+
+// maybeRewriteAOF start a background rewrite when the file is too big, must hold the cache lock
+func (c *cache) maybeRewriteAOF() {
+	a := c.aof
+	a.locker.Lock()
+	defer a.locker.Unlock()
+	if a.rewriting || a.closed || a.size < a.rewriteMinSize || a.size < 2*a.rewriteBaseSize {
+		return
+	}
+
+	a.rewriting = true
+	records := c.records()
+	go func() {
+		if err := c.rewriteAOF(records); err != nil && a.onError != nil {
+			a.onError(err)
+		}
+	}()
+}
+
+// rewriteAOF write the live keys to a new file, then append the records logged during rewriting and replace the old file,
+// the old file is kept if any write fail, the keys can not be marshaled are left out and reported
+func (c *cache) rewriteAOF(records []snapshotRecord) error {
+	a := c.aof
+	tmpPath := a.path + ".rewrite"
+	err := func() error {
+		f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+
+		w := bufio.NewWriter(f)
+		if _, err := w.Write(append([]byte(aofMagic), aofVersion)); err != nil {
+			f.Close()
+			return err
+		}
+
+		buf := make([]byte, 0, 64)
+		for _, record := range records {
+			op, value := aofOpSetByte, record.value
+			if record.isInterface {
+				v, err := a.codec.Marshal(record.raw)
+				if err != nil {
+					// left out is the same as deleted, the older records of the key are not in the new file
+					if a.onError != nil {
+						a.onError(fmt.Errorf("gocache: aof can not marshal the value of key %s: %w", record.key, err))
+					}
+					continue
+				}
+				op, value = aofOpSetInterface, v
+			}
+
+			buf = encodeAOFRecord(buf[:0], op, record.key, value, record.expireUnixNanosecondDateTime)
+			if _, err := w.Write(buf); err != nil {
+				f.Close()
+				return err
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			f.Close()
+			return err
+		}
+
+		a.locker.Lock()
+		defer a.locker.Unlock()
+		if a.closed {
+			f.Close()
+			return os.ErrClosed
+		}
+
+		if _, err := f.Write(a.rewriteBuf.Bytes()); err != nil {
+			f.Close()
+			return err
+		}
+
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+
+		if err := os.Rename(tmpPath, a.path); err != nil {
+			f.Close()
+			return err
+		}
+
+		size, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		a.file.Close()
+		a.file = f
+		a.size = size
+		a.rewriteBaseSize = size
+		return nil
+	}()
+
+	a.locker.Lock()
+	a.rewriting = false
+	a.rewriteBuf.Reset()
+	a.locker.Unlock()
+	if err != nil {
+		os.Remove(tmpPath)
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+package gocache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewWithAOF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	c, err := NewWithAOF(path, FsyncAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("a", []byte("a hi"), 10*time.Second)
+	c.SetInterface("b", 100, 10*time.Second)
+	c.Set("c", []byte("c hi"), 10*time.Second)
+	c.Delete("c")
+	c.Set("d", []byte("expired"), 10*time.Millisecond)
+	c.ShutDown()
+
+	// broken tail when crash
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.Write([]byte{aofOpSetByte, 1, 2})
+	f.Close()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c, err = NewWithAOF(path, FsyncNever)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.ShutDown()
+
+	if c.Size() != 2 {
+		t.Fatalf("size should be 2, but %d", c.Size())
+	}
+
+	if v, _, _ := c.Get("a"); string(v) != "a hi" {
+		t.Fatalf("a should be a hi, but %s", v)
+	}
+
+	if v, _, _ := c.GetInterface("b"); v != 100 {
+		t.Fatalf("b should be 100, but %v", v)
+	}
+
+	// write after the truncated tail
+	c.Set("e", []byte("e hi"), 10*time.Second)
+	c2, err := NewWithAOF(path, FsyncNever)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.ShutDown()
+
+	if v, _, _ := c2.Get("e"); string(v) != "e hi" {
+		t.Fatalf("e should be e hi, but %s", v)
+	}
+}
+
+func TestAOFRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	c, err := NewWithAOF(path, FsyncEverySecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		c.Set("a", []byte("a hi"), 10*time.Second)
+	}
+	c.Set("b", []byte("b hi"), 10*time.Second)
+
+	// start rewrite like maybeRewriteAOF
+	cc := c.(*cache)
+	cc.locker.Lock()
+	cc.aof.rewriting = true
+	records := cc.records()
+	cc.locker.Unlock()
+
+	// appended during rewriting
+	c.Set("c", []byte("c hi"), 10*time.Second)
+	if err := cc.rewriteAOF(records); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("d", []byte("d hi"), 10*time.Second)
+	c.ShutDown()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() > 200 {
+		t.Fatalf("aof should be rewritten, but size %d", info.Size())
+	}
+
+	c, err = NewWithAOF(path, FsyncNever)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.ShutDown()
+
+	if c.Size() != 4 {
+		t.Fatalf("size should be 4, but %d", c.Size())
+	}
+}
+
+func TestAOFAutoRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	c, err := NewWithAOF(path, FsyncNever, WithAOFRewriteMinSize(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.ShutDown()
+
+	for i := 0; i < 1000; i++ {
+		c.Set("a", []byte("a hi"), 10*time.Second)
+		time.Sleep(10 * time.Microsecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() >= 19000 {
+		t.Fatalf("aof should be rewritten, but size %d", info.Size())
+	}
+}
+
+func TestAOFRewriteTriggeredBySet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	c, err := NewWithAOF(path, FsyncNever, WithAOFRewriteMinSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first set double the file and start the rewrite, the rewritten file should have it
+	c.Set("a", []byte("a hi"), 10*time.Second)
+	cc := c.(*cache)
+	for {
+		cc.aof.locker.Lock()
+		rewriting := cc.aof.rewriting
+		cc.aof.locker.Unlock()
+		if !rewriting {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	c.ShutDown()
+
+	c, err = NewWithAOF(path, FsyncNever)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.ShutDown()
+
+	if value, _, exist := c.Get("a"); !exist || string(value) != "a hi" {
+		t.Fatalf("a should be kept after the rewrite, but %q %v", value, exist)
+	}
+
+	if stats := c.Stats(); stats.Sets != 0 || stats.Size != 1 {
+		t.Fatalf("replayed records should not be counted, but %+v", stats)
+	}
+}
+
+func TestAOFMarshalError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	var errs []error
+	c, err := NewWithAOF(path, FsyncNever, WithAOFErrorFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the value can not be marshaled is logged as a delete
+	c.Set("k", []byte("v1"), 10*time.Second)
+	c.SetInterface("k", make(chan int), 10*time.Second)
+	if len(errs) != 1 {
+		t.Fatalf("marshal error should be reported, but %v", errs)
+	}
+
+	// left out of the rewritten file
+	cc := c.(*cache)
+	cc.locker.Lock()
+	cc.aof.rewriting = true
+	records := cc.records()
+	cc.locker.Unlock()
+	if err := cc.rewriteAOF(records); err != nil || len(errs) != 2 {
+		t.Fatalf("rewrite should report the marshal error, but %v %v", err, errs)
+	}
+	c.ShutDown()
+
+	c, err = NewWithAOF(path, FsyncNever)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.ShutDown()
+
+	if value, _, exist := c.Get("k"); exist {
+		t.Fatalf("overwritten value should not come back, but %q", value)
+	}
+}
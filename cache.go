@@ -1,7 +1,11 @@
 package gocache
 
 import (
+	"container/list"
+	"context"
 	"github.com/hunterhug/gocache/algorithm"
+	"io"
+	"regexp"
 	"time"
 )
 
@@ -10,22 +14,94 @@ type Cache interface {
 	SetInterface(key string, value interface{}, expireTime time.Duration)
 	SetByExpireUnixNanosecondDateTime(key string, value []byte, expireUnixNanosecondDateTime int64)
 	SetInterfaceByExpireUnixNanosecondDateTime(key string, value interface{}, expireUnixNanosecondDateTime int64)
+	SetWithTags(key string, value []byte, expireTime time.Duration, tags ...string)
+	SetSliding(key string, value []byte, slidingExpiration, maxLifetime time.Duration)
+	SetInterfaceSliding(key string, value interface{}, slidingExpiration, maxLifetime time.Duration)
+	SetIfAbsent(key string, value []byte, expireTime time.Duration) bool
+	SetIfPresent(key string, value []byte, expireTime time.Duration) bool
+	GetAndSet(key string, value []byte, expireTime time.Duration) (old []byte, exist bool)
+	GetAndDelete(key string) (old []byte, exist bool)
+	CompareAndSwap(key string, old, new []byte) bool
 	Delete(key string)
+	DeleteMatching(pattern string) int
+	DeleteMatchingRegexp(re *regexp.Regexp) int
+	InvalidateTag(tag string) int
+	Incr(key string, delta int64, ttlIfCreated time.Duration) (int64, error)
+	Decr(key string, delta int64, ttlIfCreated time.Duration) (int64, error)
+	IncrByFloat(key string, delta float64, ttlIfCreated time.Duration) (float64, error)
+	Expire(key string, expireTime time.Duration) bool
+	ExpireAt(key string, t time.Time) bool
+	Persist(key string) bool
+	TTL(key string) (ttl time.Duration, exist bool)
 	Get(key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool)
 	GetInterface(key string) (value interface{}, expireUnixNanosecondDateTime int64, exist bool)
+	GetOrLoad(ctx context.Context, key string, loader Loader) (value []byte, expireUnixNanosecondDateTime int64, err error)
+	GetInterfaceOrLoad(ctx context.Context, key string, loader InterfaceLoader) (value interface{}, expireUnixNanosecondDateTime int64, err error)
 	GetOldestKey() (key string, expireUnixNanosecondDateTime int64, exist bool)
 	Size() int
 	Index(index int) (value []byte, expireUnixNanosecondDateTime int64, exist bool)
 	IndexInterface(index int) (value interface{}, expireUnixNanosecondDateTime int64, exist bool)
 	KeyList() []string
+	KeysMatching(pattern string) []string
+	ScanRange(start, end string, limit int) []Entry
+	ScanPrefix(prefix string, limit int) []Entry
+	MemoryUsage() int64
+	OnEvict(f EvictFunc)
+	SaveSnapshot(w io.Writer) error
+	LoadSnapshot(r io.Reader) error
+	Stats() Stats
+	ResetStats()
 	ShutDown()
 }
 
 func New() Cache {
+	return NewWithOptions()
+}
+
+func NewWithOptions(opts ...Option) Cache {
+	c := newCache(newOptions(opts...))
+	if c.opts.janitor {
+		go c.loopCleanExpireItem()
+	}
+	return c
+}
+
+// NewSharded new a cache which hash keys across shardNum independent shards to reduce lock contention,
+// the max entries, max memory and heap initial capacity are divided equally across shards,
+// all shards share one background janitor.
+// The limits are enforced per shard, so a shard evicts its own least recently used keys when it is full
+// even if the other shards have room, the eviction is not global.
+// Size, Index, KeyList and the other methods across shards lock the shards one by one,
+// they are not a consistent view of all shards under concurrent writes
+func NewSharded(shardNum int, opts ...Option) Cache {
+	if shardNum <= 0 {
+		shardNum = defaultShardNum
+	}
+
+	o := newOptions(opts...)
+	shardOpts := o
+	shardOpts.maxEntries = divideCeil(o.maxEntries, shardNum)
+	shardOpts.maxMemory = (o.maxMemory + int64(shardNum) - 1) / int64(shardNum)
+	shardOpts.heapInitCap = divideCeil(o.heapInitCap, shardNum)
+
+	c := new(shardedCache)
+	c.opts = o
+	c.shards = make([]*cache, shardNum)
+	for i := range c.shards {
+		c.shards[i] = newCache(shardOpts)
+	}
+
+	if o.janitor {
+		go c.loopCleanExpireItem()
+	}
+	return c
+}
+
+func newCache(o options) *cache {
 	c := new(cache)
+	c.opts = o
 	c.treeMap = algorithm.NewTreeMap()
-	c.minHeap = algorithm.NewMinHeap(nil)
-
-	go c.loopCleanExpireItem()
+	c.minHeap = algorithm.NewMinHeapWithCap(c.opts.heapInitCap)
+	c.lru = list.New()
 	return c
 }
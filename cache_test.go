@@ -2,6 +2,7 @@ package gocache
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 	"time"
 )
@@ -60,3 +61,171 @@ func TestNew2(t *testing.T) {
 		fmt.Println(c.Index(i))
 	}
 }
+
+func TestNewWithOptions(t *testing.T) {
+	c := NewWithOptions(WithMaxEntries(3))
+	defer c.ShutDown()
+
+	c.Set("a", []byte("a"), 10*time.Second)
+	c.Set("b", []byte("b"), 5*time.Second)
+	c.Set("c", []byte("c"), 20*time.Second)
+
+	// a is recently used now, b is the least recently used
+	c.Get("a")
+	c.Set("d", []byte("d"), 30*time.Second)
+
+	if c.Size() != 3 {
+		t.Fatalf("size should be 3, but %d", c.Size())
+	}
+
+	if _, _, exist := c.Get("b"); exist {
+		t.Fatal("b should be evicted")
+	}
+
+	for _, k := range []string{"a", "c", "d"} {
+		if _, _, exist := c.Get(k); !exist {
+			t.Fatalf("%s should exist", k)
+		}
+	}
+
+	oldK, _, _ := c.GetOldestKey()
+	if oldK != "a" {
+		t.Fatalf("oldest key should be a, but %s", oldK)
+	}
+}
+
+func TestWithMaxMemory(t *testing.T) {
+	c := NewWithOptions(WithMaxMemory(10), WithCostFunc(func(key string, value interface{}) int64 {
+		return int64(len(key)) + 4
+	}))
+	defer c.ShutDown()
+
+	c.Set("a", []byte("1234"), 10*time.Second)
+	c.SetInterface("b", 1, 5*time.Second)
+	if c.MemoryUsage() != 10 {
+		t.Fatalf("memory usage should be 10, but %d", c.MemoryUsage())
+	}
+
+	// b expire soonest, it will be evicted
+	c.Set("c", []byte("1"), 20*time.Second)
+	if _, _, exist := c.GetInterface("b"); exist {
+		t.Fatal("b should be evicted")
+	}
+
+	if c.MemoryUsage() != 7 {
+		t.Fatalf("memory usage should be 7, but %d", c.MemoryUsage())
+	}
+
+	c.Delete("a")
+	if c.MemoryUsage() != 2 {
+		t.Fatalf("memory usage should be 2, but %d", c.MemoryUsage())
+	}
+}
+
+func TestWithJanitor(t *testing.T) {
+	c := NewWithOptions(WithCleanInterval(10*time.Millisecond), WithCleanMaxRemovals(100), WithHeapInitCap(1000))
+	defer c.ShutDown()
+
+	noJanitor := NewWithOptions(WithJanitor(false))
+	defer noJanitor.ShutDown()
+
+	for i := 0; i < 100; i++ {
+		c.Set(fmt.Sprintf("%d", i), []byte("hi"), time.Millisecond)
+		noJanitor.Set(fmt.Sprintf("%d", i), []byte("hi"), time.Millisecond)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if c.Size() != 0 {
+		t.Fatalf("size should be 0, but %d", c.Size())
+	}
+
+	if noJanitor.Size() != 100 {
+		t.Fatalf("size should be 100, but %d", noJanitor.Size())
+	}
+}
+
+func TestOnEvict(t *testing.T) {
+	c := NewWithOptions(WithMaxEntries(2), WithJanitor(false))
+
+	reasons := make(map[string]EvictReason)
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		// call back into the cache will not dead lock
+		c.Size()
+		reasons[fmt.Sprintf("%s:%v", key, value)] = reason
+	})
+
+	c.Set("a", []byte("1"), 10*time.Second)
+	c.Set("a", []byte("2"), 10*time.Second)
+	c.SetInterface("b", 3, time.Millisecond)
+	c.Set("c", []byte("4"), 10*time.Second)
+	c.Set("d", []byte("5"), 10*time.Second)
+	c.Delete("c")
+	c.Set("e", []byte("6"), 10*time.Second)
+	c.ShutDown()
+
+	expect := map[string]EvictReason{
+		"a:[49]": EvictReasonReplaced,
+		"b:3":    EvictReasonCapacity,
+		"a:[50]": EvictReasonCapacity,
+		"c:[52]": EvictReasonDeleted,
+		"d:[53]": EvictReasonShutDown,
+		"e:[54]": EvictReasonShutDown,
+	}
+
+	if len(reasons) != len(expect) {
+		t.Fatalf("evicted should be %v, but %v", expect, reasons)
+	}
+
+	for k, v := range expect {
+		if reasons[k] != v {
+			t.Fatalf("%s evict reason should be %s, but %s", k, v, reasons[k])
+		}
+	}
+
+	c = New()
+	defer c.ShutDown()
+
+	var expired string
+	c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+		expired = key + ":" + reason.String()
+	})
+
+	c.Set("a", []byte("1"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	c.Get("a")
+	if expired != "a:expired" {
+		t.Fatalf("a should be expired, but %s", expired)
+	}
+}
+
+func TestDeleteMatching(t *testing.T) {
+	for name, c := range map[string]Cache{"cache": New(), "sharded": NewSharded(4)} {
+		for _, key := range []string{"tenant:42:user:1", "tenant:42:user:2", "tenant:42:order:1", "tenant:420:user:1", "tenant:4", "other"} {
+			c.Set(key, []byte(key), time.Minute)
+		}
+		c.Set("tenant:42:expired", []byte("expired"), -time.Second)
+
+		if n := c.DeleteMatching("tenant:42:user:*"); n != 2 {
+			t.Fatalf("%s: should delete 2 keys, but %d", name, n)
+		}
+
+		if n := c.DeleteMatching("tenant:42:*"); n != 1 || c.Size() != 3 {
+			t.Fatalf("%s: should delete 1 key and the expired key, but %d, size %d", name, n, c.Size())
+		}
+
+		if n := c.DeleteMatchingRegexp(regexp.MustCompile(`^tenant:\d+:user:1$`)); n != 1 {
+			t.Fatalf("%s: should delete tenant:420:user:1, but %d", name, n)
+		}
+
+		// not anchored
+		if n := c.DeleteMatchingRegexp(regexp.MustCompile(`the`)); n != 1 || c.KeyList()[0] != "tenant:4" {
+			t.Fatalf("%s: should delete other, but %d %v", name, n, c.KeyList())
+		}
+
+		if c.Stats().Deletes != 5 {
+			t.Fatalf("%s: deletes should be 5, but %d", name, c.Stats().Deletes)
+		}
+		c.ShutDown()
+	}
+}
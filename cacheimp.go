@@ -1,7 +1,11 @@
 package gocache
 
 import (
+	"container/list"
 	"github.com/hunterhug/gocache/algorithm"
+	"github.com/hunterhug/gocache/internal/loadgroup"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 )
@@ -9,14 +13,46 @@ import (
 type cache struct {
 	minHeap *algorithm.Heap
 	treeMap algorithm.TreeMap
-	close   bool
-	locker  sync.Mutex
+	// the keys never expire, kept outside the heap, index of the heap value is its position
+	persistent []*algorithm.HeapValue
+	// recently used list, front is the most recently used, element value is *algorithm.HeapValue
+	lru *list.List
+	// total cost of all keys
+	memoryUsage int64
+	opts        options
+	onEvict     EvictFunc
+	// removed keys wait to call onEvict after unlock
+	evicted []evictedItem
+	// errors of the append only file wait to report after unlock
+	aofErrors []error
+	// coalesce the concurrent loads of GetOrLoad and GetInterfaceOrLoad
+	loadGroup          loadgroup.Group
+	interfaceLoadGroup loadgroup.Group
+	// reverse index from the tags to the keys set by SetWithTags
+	tags map[string]map[string]struct{}
+	// append only file, nil when not enabled
+	aof    *aof
+	stats  cacheStats
+	close  bool
+	locker sync.Mutex
 }
 
 type cacheItem struct {
 	RawByte                      []byte
 	Raw                          interface{}
+	isInterface                  bool
 	expireUnixNanosecondDateTime int64
+	lruElement                   *list.Element
+	cost                         int64
+	// reload the value when refresh ahead, only the key set by GetOrLoad has it
+	reload     reloadFunc
+	refreshing bool
+	// tags set by SetWithTags
+	tags []string
+	// each get push the expire time forward by sliding, not later than maxExpireUnixNanosecondDateTime if it is not 0,
+	// only the key set by SetSliding has it
+	sliding                         time.Duration
+	maxExpireUnixNanosecondDateTime int64
 }
 
 func (i *cacheItem) GetExpireUnixNanosecondDateTime() int64 {
@@ -27,31 +63,43 @@ func (i *cacheItem) IsExpire() bool {
 	return i.expireUnixNanosecondDateTime <= time.Now().UnixNano()
 }
 
+func (i *cacheItem) value() interface{} {
+	if i.RawByte != nil {
+		return i.RawByte
+	}
+
+	return i.Raw
+}
+
 func (c *cache) loopCleanExpireItem() {
-	timer := time.NewTimer(time.Second)
+	timer := time.NewTimer(c.opts.cleanInterval)
 	for {
-		if c.close {
-			timer.Stop()
-			return
-		}
 		select {
 		case <-timer.C:
-			c.cleanOlder()
-			timer.Reset(time.Second)
+			start := time.Now()
+			closed := c.cleanOlder()
+			c.stats.janitor.observe(time.Since(start))
+			if closed {
+				timer.Stop()
+				return
+			}
+			timer.Reset(c.opts.cleanInterval)
 		}
 	}
 }
 
-func (c *cache) cleanOlder() {
+// cleanOlder remove the expired keys, return true if the cache is closed
+func (c *cache) cleanOlder() (closed bool) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		c.minHeap = nil
-		return
+		c.persistent = nil
+		return true
 	}
 
 	i := 0
-	for i < 30 {
+	for i < c.opts.cleanMaxRemovals {
 		min := c.minHeap.Min()
 		if min == nil {
 			return
@@ -61,21 +109,39 @@ func (c *cache) cleanOlder() {
 			return
 		}
 
-		c.treeMap.Delete(min.Key)
-		c.minHeap.Pop()
+		c.remove(min, EvictReasonExpired)
+		c.stats.janitorExpirations.Add(1)
 		i++
 	}
+
+	return
 }
 
 func (c *cache) ShutDown() {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	if c.onEvict != nil {
+		for i := 0; i < c.size(); i++ {
+			h := c.at(i)
+			c.addEvicted(h.Key, h.Extra.(*cacheItem), EvictReasonShutDown)
+		}
+	}
+
+	if c.aof != nil {
+		c.aof.close()
+	}
+
 	c.close = true
 }
 
 func (c *cache) Set(key string, value []byte, expireTime time.Duration) {
 	item := cacheItem{
 		RawByte: value,
+		cost:    int64(len(key) + len(value)),
 	}
 
 	c.set(key, item, expireTime)
@@ -83,7 +149,9 @@ func (c *cache) Set(key string, value []byte, expireTime time.Duration) {
 
 func (c *cache) SetInterface(key string, value interface{}, expireTime time.Duration) {
 	item := cacheItem{
-		Raw: value,
+		Raw:         value,
+		isInterface: true,
+		cost:        c.interfaceCost(key, value),
 	}
 
 	c.set(key, item, expireTime)
@@ -92,6 +160,7 @@ func (c *cache) SetInterface(key string, value interface{}, expireTime time.Dura
 func (c *cache) SetByExpireUnixNanosecondDateTime(key string, value []byte, expireUnixNanosecondDateTime int64) {
 	item := cacheItem{
 		RawByte: value,
+		cost:    int64(len(key) + len(value)),
 	}
 
 	c.setByExpireDateTime(key, item, expireUnixNanosecondDateTime)
@@ -99,20 +168,37 @@ func (c *cache) SetByExpireUnixNanosecondDateTime(key string, value []byte, expi
 
 func (c *cache) SetInterfaceByExpireUnixNanosecondDateTime(key string, value interface{}, expireUnixNanosecondDateTime int64) {
 	item := cacheItem{
-		Raw: value,
+		Raw:         value,
+		isInterface: true,
+		cost:        c.interfaceCost(key, value),
 	}
 
 	c.setByExpireDateTime(key, item, expireUnixNanosecondDateTime)
 }
 
+func (c *cache) interfaceCost(key string, value interface{}) int64 {
+	if c.opts.costFunc == nil {
+		return int64(len(key))
+	}
+
+	return c.opts.costFunc(key, value)
+}
+
 func (c *cache) setByExpireDateTime(key string, value cacheItem, expireUnixNanosecondDateTime int64) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return
 	}
 
+	c.put(key, value, expireUnixNanosecondDateTime)
+}
+
+// put the key into heap, tree map and recently used list, must hold the lock,
+// the set is logged after the state changed, so the rewrite it may start see it
+func (c *cache) put(key string, value cacheItem, expireUnixNanosecondDateTime int64) {
 	value.expireUnixNanosecondDateTime = expireUnixNanosecondDateTime
+	c.stats.sets.Add(1)
 
 	oldTreeMapValue, exist := c.treeMap.Get(key)
 	if !exist {
@@ -121,50 +207,83 @@ func (c *cache) setByExpireDateTime(key string, value cacheItem, expireUnixNanos
 			Key:   key,
 			Extra: &value,
 		}
+		value.lruElement = c.lru.PushFront(innerValue)
 		c.treeMap.Put(key, innerValue)
-		c.minHeap.Push(innerValue)
+		c.track(innerValue)
+		c.memoryUsage += value.cost
+		c.addTags(key, value.tags)
+		c.appendAOFSet(key, &value)
+		c.evict()
 		return
 	}
 
-	oldTreeMapValueReal := oldTreeMapValue.(*algorithm.HeapValue)
-	oldHeapValue := c.minHeap.PopIndex(oldTreeMapValueReal.Index)
-	oldHeapValue.Value = expireUnixNanosecondDateTime
+	oldHeapValue := oldTreeMapValue.(*algorithm.HeapValue)
+	oldItem := oldHeapValue.Extra.(*cacheItem)
+	c.addEvicted(key, oldItem, EvictReasonReplaced)
+	value.lruElement = oldItem.lruElement
+	c.lru.MoveToFront(value.lruElement)
+	c.memoryUsage += value.cost - oldItem.cost
+	c.removeTags(key, oldItem.tags)
+	c.addTags(key, value.tags)
 	oldHeapValue.Extra = &value
-	c.minHeap.Push(oldHeapValue)
+	c.setExpire(oldHeapValue, expireUnixNanosecondDateTime)
+	c.appendAOFSet(key, &value)
+	c.evict()
 }
 
 func (c *cache) set(key string, value cacheItem, expireTime time.Duration) {
-	c.locker.Lock()
-	defer c.locker.Unlock()
-	if c.close {
-		return
-	}
+	c.setByExpireDateTime(key, value, ExpireAfter(expireTime))
+}
 
-	expireUnixNanosecondDateTime := time.Now().UnixNano() + int64(expireTime/time.Nanosecond)
-	value.expireUnixNanosecondDateTime = expireUnixNanosecondDateTime
+// evict the least recently used keys until the cache fit the max entries,
+// then evict the soonest expiring keys until the cache fit the max memory, the keys never expire are evicted last
+func (c *cache) evict() {
+	if c.opts.maxEntries > 0 {
+		for c.size() > c.opts.maxEntries {
+			back := c.lru.Back()
+			if back == nil {
+				break
+			}
+
+			c.remove(back.Value.(*algorithm.HeapValue), EvictReasonCapacity)
+			c.stats.capacityEvictions.Add(1)
+		}
+	}
 
-	oldTreeMapValue, exist := c.treeMap.Get(key)
-	if !exist {
-		innerValue := &algorithm.HeapValue{
-			Value: expireUnixNanosecondDateTime,
-			Key:   key,
-			Extra: &value,
+	if c.opts.maxMemory > 0 {
+		for c.memoryUsage > c.opts.maxMemory {
+			min := c.minHeap.Min()
+			if min == nil {
+				back := c.lru.Back()
+				if back == nil {
+					break
+				}
+				min = back.Value.(*algorithm.HeapValue)
+			}
+
+			c.remove(min, EvictReasonCapacity)
+			c.stats.capacityEvictions.Add(1)
 		}
-		c.treeMap.Put(key, innerValue)
-		c.minHeap.Push(innerValue)
-		return
 	}
+}
 
-	oldTreeMapValueReal := oldTreeMapValue.(*algorithm.HeapValue)
-	oldHeapValue := c.minHeap.PopIndex(oldTreeMapValueReal.Index)
-	oldHeapValue.Value = expireUnixNanosecondDateTime
-	oldHeapValue.Extra = &value
-	c.minHeap.Push(oldHeapValue)
+// remove the key from heap, tree map and recently used list
+func (c *cache) remove(heapValue *algorithm.HeapValue, reason EvictReason) {
+	c.untrack(heapValue)
+	c.treeMap.Delete(heapValue.Key)
+	item := heapValue.Extra.(*cacheItem)
+	c.addEvicted(heapValue.Key, item, reason)
+	if reason == EvictReasonDeleted || reason == EvictReasonCapacity {
+		c.appendAOFDelete(heapValue.Key)
+	}
+	c.lru.Remove(item.lruElement)
+	c.memoryUsage -= item.cost
+	c.removeTags(heapValue.Key, item.tags)
 }
 
 func (c *cache) Delete(key string) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return
 	}
@@ -174,70 +293,122 @@ func (c *cache) Delete(key string) {
 		return
 	}
 
-	treeMapValueReal := treeMapValue.(*algorithm.HeapValue)
-	c.minHeap.PopIndex(treeMapValueReal.Index)
-	c.treeMap.Delete(key)
+	c.remove(treeMapValue.(*algorithm.HeapValue), EvictReasonDeleted)
+	c.stats.deletes.Add(1)
+}
+
+// DeleteMatching delete the keys matching the redis style glob pattern, return the number of deleted keys
+func (c *cache) DeleteMatching(pattern string) int {
+	return c.deleteMatching(patternPrefix(pattern), func(key string) bool {
+		return MatchPattern(pattern, key)
+	})
+}
+
+// DeleteMatchingRegexp delete the keys matching the regular expression, return the number of deleted keys,
+// only the keys with the literal prefix are walked when the expression start with ^
+func (c *cache) DeleteMatchingRegexp(re *regexp.Regexp) int {
+	return c.deleteMatching(regexpPrefix(re), re.MatchString)
+}
+
+// deleteMatching walk only the keys with the literal prefix and delete the matched ones in one critical section
+func (c *cache) deleteMatching(prefix string, match func(key string) bool) int {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return 0
+	}
+
+	var matched []*algorithm.HeapValue
+	c.treeMap.RangeFrom(prefix, func(key string, value interface{}) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+
+		if match(key) {
+			matched = append(matched, value.(*algorithm.HeapValue))
+		}
+		return true
+	})
+
+	return c.deleteAll(matched)
+}
+
+// deleteAll delete the keys, the expired keys are removed too but not counted, must hold the lock
+func (c *cache) deleteAll(heapValues []*algorithm.HeapValue) int {
+	deleted := 0
+	for _, heapValue := range heapValues {
+		if heapValue.Extra.(*cacheItem).IsExpire() {
+			c.remove(heapValue, EvictReasonExpired)
+			c.stats.lazyExpirations.Add(1)
+			continue
+		}
+
+		c.remove(heapValue, EvictReasonDeleted)
+		c.stats.deletes.Add(1)
+		deleted++
+	}
+
+	return deleted
 }
 
-func (c *cache) get(key string) (value *cacheItem, exist bool) {
+// get copy the value and the expire time under the lock, the sliding expiration and Expire change them in place
+func (c *cache) get(key string) (rawByte []byte, raw interface{}, expireUnixNanosecondDateTime int64, exist bool) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return
 	}
 
 	treeMapValue, exist := c.treeMap.Get(key)
 	if !exist {
-		return nil, false
+		c.stats.misses.Add(1)
+		return nil, nil, 0, false
 	}
 
 	treeMapValueReal := treeMapValue.(*algorithm.HeapValue)
 	item := treeMapValueReal.Extra.(*cacheItem)
 	if item.IsExpire() {
-		c.minHeap.PopIndex(treeMapValueReal.Index)
-		c.treeMap.Delete(key)
-		return nil, false
+		c.remove(treeMapValueReal, EvictReasonExpired)
+		c.stats.lazyExpirations.Add(1)
+		c.stats.misses.Add(1)
+		return nil, nil, 0, false
 	}
 
-	return item, true
+	c.stats.hits.Add(1)
+	c.lru.MoveToFront(item.lruElement)
+	c.slide(treeMapValueReal)
+	c.refreshAhead(key, item)
+	return item.RawByte, item.Raw, item.expireUnixNanosecondDateTime, true
 }
 
 func (c *cache) Get(key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool) {
-	result, has := c.get(key)
-	if has {
-		return result.RawByte, result.expireUnixNanosecondDateTime, true
-	}
-
+	value, _, expireUnixNanosecondDateTime, exist = c.get(key)
 	return
 }
 
 func (c *cache) GetInterface(key string) (value interface{}, expireUnixNanosecondDateTime int64, exist bool) {
-	result, has := c.get(key)
-	if has {
-		return result.Raw, result.expireUnixNanosecondDateTime, true
-	}
-
+	_, value, expireUnixNanosecondDateTime, exist = c.get(key)
 	return
 }
 
 func (c *cache) Size() int {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return 0
 	}
 
-	return c.minHeap.Size()
+	return c.size()
 }
 
 func (c *cache) IndexInterface(index int) (value interface{}, expireUnixNanosecondDateTime int64, exist bool) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return
 	}
 
-	h := c.minHeap.Get(index)
+	h := c.at(index)
 	if h == nil {
 		return
 	}
@@ -248,12 +419,12 @@ func (c *cache) IndexInterface(index int) (value interface{}, expireUnixNanoseco
 
 func (c *cache) Index(index int) (value []byte, expireUnixNanosecondDateTime int64, exist bool) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return
 	}
 
-	h := c.minHeap.Get(index)
+	h := c.at(index)
 	if h == nil {
 		return
 	}
@@ -262,15 +433,15 @@ func (c *cache) Index(index int) (value []byte, expireUnixNanosecondDateTime int
 	return item.RawByte, item.expireUnixNanosecondDateTime, true
 }
 
+// GetOldestKey return the key expire first, the keys never expire are skipped, not exist when only they remain
 func (c *cache) GetOldestKey() (key string, expireUnixNanosecondDateTime int64, exist bool) {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return
 	}
 
-	min := c.minHeap.Min()
-	if min != nil {
+	if min := c.minHeap.Min(); min != nil {
 		return min.Key, min.Value, true
 	}
 
@@ -279,10 +450,20 @@ func (c *cache) GetOldestKey() (key string, expireUnixNanosecondDateTime int64,
 
 func (c *cache) KeyList() []string {
 	c.locker.Lock()
-	defer c.locker.Unlock()
+	defer c.unlock()
 	if c.close {
 		return nil
 	}
 
 	return c.treeMap.KeyList()
 }
+
+func (c *cache) MemoryUsage() int64 {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return 0
+	}
+
+	return c.memoryUsage
+}
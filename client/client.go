@@ -0,0 +1,738 @@
+// Package client implement gocache.Cache over the network, it speak the redis protocol to the resp server of gocache-server
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/hunterhug/gocache"
+	"github.com/hunterhug/gocache/internal/loadgroup"
+	"github.com/hunterhug/gocache/resp"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var ErrUnexpectedReply = errors.New("client: unexpected reply")
+
+// Client a remote cache, the methods of gocache.Cache drop the errors and return the zero values,
+// use the Context variants to get the errors,
+// the value set by SetInterface is encoded by the codec and stored as bytes in the server
+type Client struct {
+	pool               *pool
+	opts               options
+	loadGroup          loadgroup.Group
+	interfaceLoadGroup loadgroup.Group
+}
+
+var _ gocache.Cache = (*Client)(nil)
+
+// New new a client of the gocache server listen on addr by -resp-addr
+func New(addr string, opts ...Option) *Client {
+	c := new(Client)
+	c.opts = newOptions(opts...)
+	c.pool = &pool{addr: addr, opts: c.opts}
+	return c
+}
+
+// Entry the result of GetMulti, Exist is false when the key is missing, Raw is not used
+type Entry struct {
+	gocache.Entry
+	Exist bool
+}
+
+func command(name string, args ...string) [][]byte {
+	cmd := make([][]byte, 0, len(args)+1)
+	cmd = append(cmd, []byte(name))
+	for _, arg := range args {
+		cmd = append(cmd, []byte(arg))
+	}
+	return cmd
+}
+
+func setCommand(key string, value []byte, expireUnixNanosecondDateTime int64) [][]byte {
+	return [][]byte{[]byte("GOCACHE.SET"), []byte(key), value, []byte(strconv.FormatInt(expireUnixNanosecondDateTime, 10))}
+}
+
+// do send one command and return its reply, the error reply is returned as error
+func (c *Client) do(ctx context.Context, args ...[]byte) (resp.Value, error) {
+	values, err := c.pool.do(ctx, true, args)
+	if err != nil {
+		return resp.Value{}, err
+	}
+
+	return values[0], values[0].Err()
+}
+
+// doOnce send one command which is not idempotent, it is not retried after sent
+func (c *Client) doOnce(ctx context.Context, args ...[]byte) (resp.Value, error) {
+	values, err := c.pool.do(ctx, false, args)
+	if err != nil {
+		return resp.Value{}, err
+	}
+
+	return values[0], values[0].Err()
+}
+
+// parseEntry parse the [value, expire] reply
+func parseEntry(v resp.Value) (value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	if v.Null {
+		return nil, 0, false, nil
+	}
+
+	if v.Type != '*' || len(v.Array) != 2 {
+		return nil, 0, false, ErrUnexpectedReply
+	}
+
+	return v.Array[0].Str, v.Array[1].Int, true, nil
+}
+
+func (c *Client) decode(value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) (interface{}, int64, bool, error) {
+	if !exist || err != nil {
+		return nil, 0, false, err
+	}
+
+	v, err := c.opts.codec.Unmarshal(value)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return v, expireUnixNanosecondDateTime, true, nil
+}
+
+func (c *Client) SetContext(ctx context.Context, key string, value []byte, expireTime time.Duration) error {
+	return c.SetByExpireUnixNanosecondDateTimeContext(ctx, key, value, gocache.ExpireAfter(expireTime))
+}
+
+func (c *Client) SetByExpireUnixNanosecondDateTimeContext(ctx context.Context, key string, value []byte, expireUnixNanosecondDateTime int64) error {
+	_, err := c.do(ctx, setCommand(key, value, expireUnixNanosecondDateTime)...)
+	return err
+}
+
+func (c *Client) SetInterfaceContext(ctx context.Context, key string, value interface{}, expireTime time.Duration) error {
+	return c.SetInterfaceByExpireUnixNanosecondDateTimeContext(ctx, key, value, gocache.ExpireAfter(expireTime))
+}
+
+func (c *Client) SetInterfaceByExpireUnixNanosecondDateTimeContext(ctx context.Context, key string, value interface{}, expireUnixNanosecondDateTime int64) error {
+	data, err := c.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.SetByExpireUnixNanosecondDateTimeContext(ctx, key, data, expireUnixNanosecondDateTime)
+}
+
+func (c *Client) SetWithTagsContext(ctx context.Context, key string, value []byte, expireTime time.Duration, tags ...string) error {
+	args := [][]byte{[]byte("GOCACHE.SETTAGS"), []byte(key), value, []byte(strconv.FormatInt(int64(expireTime), 10))}
+	for _, tag := range tags {
+		args = append(args, []byte(tag))
+	}
+
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// SetSlidingContext see gocache.Cache SetSliding, the expire time slide when the key is read from the server
+func (c *Client) SetSlidingContext(ctx context.Context, key string, value []byte, slidingExpiration, maxLifetime time.Duration) error {
+	sliding, lifetime := strconv.FormatInt(int64(slidingExpiration), 10), strconv.FormatInt(int64(maxLifetime), 10)
+	_, err := c.do(ctx, []byte("GOCACHE.SETSLIDING"), []byte(key), value, []byte(sliding), []byte(lifetime))
+	return err
+}
+
+func (c *Client) SetInterfaceSlidingContext(ctx context.Context, key string, value interface{}, slidingExpiration, maxLifetime time.Duration) error {
+	data, err := c.opts.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return c.SetSlidingContext(ctx, key, data, slidingExpiration, maxLifetime)
+}
+
+// setIf send GOCACHE.SETIF with the condition NX, XX or GET, the command is not retried
+func (c *Client) setIf(ctx context.Context, key string, value []byte, expireTime time.Duration, condition string) (resp.Value, error) {
+	return c.doOnce(ctx, []byte("GOCACHE.SETIF"), []byte(key), value, []byte(strconv.FormatInt(int64(expireTime), 10)), []byte(condition))
+}
+
+func (c *Client) SetIfAbsentContext(ctx context.Context, key string, value []byte, expireTime time.Duration) (bool, error) {
+	v, err := c.setIf(ctx, key, value, expireTime, "NX")
+	return err == nil && !v.Null, err
+}
+
+func (c *Client) SetIfPresentContext(ctx context.Context, key string, value []byte, expireTime time.Duration) (bool, error) {
+	v, err := c.setIf(ctx, key, value, expireTime, "XX")
+	return err == nil && !v.Null, err
+}
+
+func (c *Client) GetAndSetContext(ctx context.Context, key string, value []byte, expireTime time.Duration) (old []byte, exist bool, err error) {
+	v, err := c.setIf(ctx, key, value, expireTime, "GET")
+	if err != nil || v.Null {
+		return nil, false, err
+	}
+
+	return v.Str, true, nil
+}
+
+// GetAndDeleteContext the command is not retried, see gocache.Cache GetAndDelete
+func (c *Client) GetAndDeleteContext(ctx context.Context, key string) (old []byte, exist bool, err error) {
+	v, err := c.doOnce(ctx, command("GETDEL", key)...)
+	if err != nil || v.Null {
+		return nil, false, err
+	}
+
+	return v.Str, true, nil
+}
+
+// CompareAndSwapContext the command is not retried, see gocache.Cache CompareAndSwap
+func (c *Client) CompareAndSwapContext(ctx context.Context, key string, old, new []byte) (bool, error) {
+	v, err := c.doOnce(ctx, []byte("GOCACHE.CAS"), []byte(key), old, new)
+	return err == nil && v.Int == 1, err
+}
+
+func (c *Client) ExpireContext(ctx context.Context, key string, expireTime time.Duration) (bool, error) {
+	return c.ExpireAtContext(ctx, key, time.Unix(0, gocache.ExpireAfter(expireTime)))
+}
+
+func (c *Client) ExpireAtContext(ctx context.Context, key string, t time.Time) (bool, error) {
+	v, err := c.do(ctx, command("GOCACHE.EXPIREAT", key, strconv.FormatInt(t.UnixNano(), 10))...)
+	return err == nil && v.Int == 1, err
+}
+
+func (c *Client) PersistContext(ctx context.Context, key string) (bool, error) {
+	v, err := c.do(ctx, command("PERSIST", key)...)
+	return err == nil && v.Int == 1, err
+}
+
+// TTLContext the ttl is gocache.NoExpiration if the key never expire
+func (c *Client) TTLContext(ctx context.Context, key string) (ttl time.Duration, exist bool, err error) {
+	v, err := c.do(ctx, command("GOCACHE.TTL", key)...)
+	if err != nil || v.Int == -2 {
+		return 0, false, err
+	}
+
+	if v.Int == -1 {
+		return gocache.NoExpiration, true, nil
+	}
+
+	return time.Duration(v.Int), true, nil
+}
+
+func (c *Client) DeleteContext(ctx context.Context, key string) error {
+	_, err := c.do(ctx, command("DEL", key)...)
+	return err
+}
+
+func (c *Client) InvalidateTagContext(ctx context.Context, tag string) (int, error) {
+	v, err := c.do(ctx, command("GOCACHE.DELTAG", tag)...)
+	return int(v.Int), err
+}
+
+// numericErrors the error replies of the counters
+var numericErrors = map[resp.Error]error{
+	"ERR value is not an integer or out of range": gocache.ErrNotInteger,
+	"ERR value is not a valid float":              gocache.ErrNotFloat,
+	"ERR increment or decrement would overflow":   gocache.ErrOverflow,
+}
+
+// numericError return the error reply of the counters as *gocache.NumericError
+func numericError(key string, err error) error {
+	var e resp.Error
+	if errors.As(err, &e) && numericErrors[e] != nil {
+		return &gocache.NumericError{Key: key, Err: numericErrors[e]}
+	}
+
+	return err
+}
+
+// IncrContext the command is not retried, see gocache.Cache Incr
+func (c *Client) IncrContext(ctx context.Context, key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	v, err := c.doOnce(ctx, command("GOCACHE.INCRBY", key, strconv.FormatInt(delta, 10), strconv.FormatInt(int64(ttlIfCreated), 10))...)
+	if err != nil {
+		return 0, numericError(key, err)
+	}
+
+	return v.Int, nil
+}
+
+func (c *Client) DecrContext(ctx context.Context, key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, &gocache.NumericError{Key: key, Err: gocache.ErrOverflow}
+	}
+
+	return c.IncrContext(ctx, key, -delta, ttlIfCreated)
+}
+
+func (c *Client) IncrByFloatContext(ctx context.Context, key string, delta float64, ttlIfCreated time.Duration) (float64, error) {
+	v, err := c.doOnce(ctx, command("GOCACHE.INCRFLOAT", key, strconv.FormatFloat(delta, 'f', -1, 64), strconv.FormatInt(int64(ttlIfCreated), 10))...)
+	if err != nil {
+		return 0, numericError(key, err)
+	}
+
+	f, err := strconv.ParseFloat(string(v.Str), 64)
+	if err != nil {
+		return 0, ErrUnexpectedReply
+	}
+	return f, nil
+}
+
+func (c *Client) DeleteMatchingContext(ctx context.Context, pattern string) (int, error) {
+	v, err := c.do(ctx, command("GOCACHE.DELMATCH", pattern)...)
+	return int(v.Int), err
+}
+
+// DeleteMatchingRegexpContext the expression is compiled again by the server
+func (c *Client) DeleteMatchingRegexpContext(ctx context.Context, re *regexp.Regexp) (int, error) {
+	v, err := c.do(ctx, command("GOCACHE.DELREGEXP", re.String())...)
+	return int(v.Int), err
+}
+
+func (c *Client) GetContext(ctx context.Context, key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	v, err := c.do(ctx, command("GOCACHE.GET", key)...)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return parseEntry(v)
+}
+
+func (c *Client) GetInterfaceContext(ctx context.Context, key string) (value interface{}, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	return c.decode(c.GetContext(ctx, key))
+}
+
+func (c *Client) GetOldestKeyContext(ctx context.Context) (key string, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	v, err := c.do(ctx, command("GOCACHE.OLDEST")...)
+	if err != nil {
+		return "", 0, false, err
+	}
+
+	k, expireUnixNanosecondDateTime, exist, err := parseEntry(v)
+	return string(k), expireUnixNanosecondDateTime, exist, err
+}
+
+func (c *Client) SizeContext(ctx context.Context) (int, error) {
+	v, err := c.do(ctx, command("DBSIZE")...)
+	return int(v.Int), err
+}
+
+func (c *Client) IndexContext(ctx context.Context, index int) (value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	v, err := c.do(ctx, command("GOCACHE.INDEX", strconv.Itoa(index))...)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return parseEntry(v)
+}
+
+func (c *Client) IndexInterfaceContext(ctx context.Context, index int) (value interface{}, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	return c.decode(c.IndexContext(ctx, index))
+}
+
+func (c *Client) KeyListContext(ctx context.Context) ([]string, error) {
+	return c.KeysMatchingContext(ctx, "*")
+}
+
+func (c *Client) KeysMatchingContext(ctx context.Context, pattern string) ([]string, error) {
+	v, err := c.do(ctx, command("KEYS", pattern)...)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(v.Array))
+	for _, key := range v.Array {
+		keys = append(keys, string(key.Str))
+	}
+	return keys, nil
+}
+
+// parseEntries parse the flat [key, value, expire, ...] reply of the scans
+func parseEntries(v resp.Value) ([]gocache.Entry, error) {
+	if v.Type != '*' || len(v.Array)%3 != 0 {
+		return nil, ErrUnexpectedReply
+	}
+
+	entries := make([]gocache.Entry, 0, len(v.Array)/3)
+	for i := 0; i < len(v.Array); i += 3 {
+		entries = append(entries, gocache.Entry{
+			Key:                          string(v.Array[i].Str),
+			Value:                        v.Array[i+1].Str,
+			ExpireUnixNanosecondDateTime: v.Array[i+2].Int,
+		})
+	}
+	return entries, nil
+}
+
+// ScanRangeContext the values are returned as bytes, the Raw of the entries is always nil
+func (c *Client) ScanRangeContext(ctx context.Context, start, end string, limit int) ([]gocache.Entry, error) {
+	v, err := c.do(ctx, command("GOCACHE.SCANRANGE", start, end, strconv.Itoa(limit))...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseEntries(v)
+}
+
+func (c *Client) ScanPrefixContext(ctx context.Context, prefix string, limit int) ([]gocache.Entry, error) {
+	v, err := c.do(ctx, command("GOCACHE.SCANPREFIX", prefix, strconv.Itoa(limit))...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseEntries(v)
+}
+
+func (c *Client) MemoryUsageContext(ctx context.Context) (int64, error) {
+	v, err := c.do(ctx, command("GOCACHE.MEMORY")...)
+	return v.Int, err
+}
+
+func (c *Client) StatsContext(ctx context.Context) (stats gocache.Stats, err error) {
+	v, err := c.do(ctx, command("GOCACHE.STATS")...)
+	if err != nil {
+		return
+	}
+
+	fields := map[string]*uint64{
+		"hits":                &stats.Hits,
+		"misses":              &stats.Misses,
+		"sets":                &stats.Sets,
+		"deletes":             &stats.Deletes,
+		"lazy_expirations":    &stats.LazyExpirations,
+		"janitor_expirations": &stats.JanitorExpirations,
+		"capacity_evictions":  &stats.CapacityEvictions,
+		"janitor_runs":        &stats.JanitorRuns,
+	}
+	for i := range stats.JanitorRunDurationBuckets {
+		fields["janitor_run_duration_bucket_"+strconv.Itoa(i)] = &stats.JanitorRunDurationBuckets[i]
+	}
+
+	for i := 0; i+1 < len(v.Array); i += 2 {
+		name, n := string(v.Array[i].Str), v.Array[i+1].Int
+		switch name {
+		case "size":
+			stats.Size = int(n)
+		case "janitor_run_duration":
+			stats.JanitorRunDuration = time.Duration(n)
+		default:
+			if field, ok := fields[name]; ok {
+				*field = uint64(n)
+			}
+		}
+	}
+	return
+}
+
+func (c *Client) ResetStatsContext(ctx context.Context) error {
+	_, err := c.do(ctx, command("GOCACHE.RESETSTATS")...)
+	return err
+}
+
+func (c *Client) SaveSnapshotContext(ctx context.Context, w io.Writer) error {
+	v, err := c.do(ctx, command("GOCACHE.SNAPSHOT")...)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(v.Str)
+	return err
+}
+
+func (c *Client) LoadSnapshotContext(ctx context.Context, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(ctx, []byte("GOCACHE.RESTORE"), data)
+	return err
+}
+
+// GetMulti get the keys in one round trip
+func (c *Client) GetMulti(ctx context.Context, keys ...string) ([]Entry, error) {
+	commands := make([][][]byte, 0, len(keys))
+	for _, key := range keys {
+		commands = append(commands, command("GOCACHE.GET", key))
+	}
+
+	values, err := c.pool.do(ctx, true, commands...)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(keys))
+	for i, v := range values {
+		if err := v.Err(); err != nil {
+			return nil, err
+		}
+
+		entries[i].Key = keys[i]
+		entries[i].Value, entries[i].ExpireUnixNanosecondDateTime, entries[i].Exist, err = parseEntry(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// SetMulti set the entries with their expire time in one round trip, Raw is not used
+func (c *Client) SetMulti(ctx context.Context, entries ...gocache.Entry) error {
+	commands := make([][][]byte, 0, len(entries))
+	for _, e := range entries {
+		commands = append(commands, setCommand(e.Key, e.Value, e.ExpireUnixNanosecondDateTime))
+	}
+
+	return c.doMulti(ctx, commands)
+}
+
+// DeleteMulti delete the keys in one round trip
+func (c *Client) DeleteMulti(ctx context.Context, keys ...string) error {
+	commands := make([][][]byte, 0, len(keys))
+	for _, key := range keys {
+		commands = append(commands, command("DEL", key))
+	}
+
+	return c.doMulti(ctx, commands)
+}
+
+func (c *Client) doMulti(ctx context.Context, commands [][][]byte) error {
+	values, err := c.pool.do(ctx, true, commands...)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		if err := v.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) Set(key string, value []byte, expireTime time.Duration) {
+	c.SetContext(context.Background(), key, value, expireTime)
+}
+
+func (c *Client) SetInterface(key string, value interface{}, expireTime time.Duration) {
+	c.SetInterfaceContext(context.Background(), key, value, expireTime)
+}
+
+func (c *Client) SetByExpireUnixNanosecondDateTime(key string, value []byte, expireUnixNanosecondDateTime int64) {
+	c.SetByExpireUnixNanosecondDateTimeContext(context.Background(), key, value, expireUnixNanosecondDateTime)
+}
+
+func (c *Client) SetInterfaceByExpireUnixNanosecondDateTime(key string, value interface{}, expireUnixNanosecondDateTime int64) {
+	c.SetInterfaceByExpireUnixNanosecondDateTimeContext(context.Background(), key, value, expireUnixNanosecondDateTime)
+}
+
+func (c *Client) Delete(key string) {
+	c.DeleteContext(context.Background(), key)
+}
+
+func (c *Client) SetWithTags(key string, value []byte, expireTime time.Duration, tags ...string) {
+	c.SetWithTagsContext(context.Background(), key, value, expireTime, tags...)
+}
+
+func (c *Client) SetSliding(key string, value []byte, slidingExpiration, maxLifetime time.Duration) {
+	c.SetSlidingContext(context.Background(), key, value, slidingExpiration, maxLifetime)
+}
+
+func (c *Client) SetInterfaceSliding(key string, value interface{}, slidingExpiration, maxLifetime time.Duration) {
+	c.SetInterfaceSlidingContext(context.Background(), key, value, slidingExpiration, maxLifetime)
+}
+
+func (c *Client) InvalidateTag(tag string) int {
+	n, _ := c.InvalidateTagContext(context.Background(), tag)
+	return n
+}
+
+func (c *Client) SetIfAbsent(key string, value []byte, expireTime time.Duration) bool {
+	set, _ := c.SetIfAbsentContext(context.Background(), key, value, expireTime)
+	return set
+}
+
+func (c *Client) SetIfPresent(key string, value []byte, expireTime time.Duration) bool {
+	set, _ := c.SetIfPresentContext(context.Background(), key, value, expireTime)
+	return set
+}
+
+func (c *Client) GetAndDelete(key string) (old []byte, exist bool) {
+	old, exist, _ = c.GetAndDeleteContext(context.Background(), key)
+	return
+}
+
+func (c *Client) GetAndSet(key string, value []byte, expireTime time.Duration) (old []byte, exist bool) {
+	old, exist, _ = c.GetAndSetContext(context.Background(), key, value, expireTime)
+	return
+}
+
+func (c *Client) CompareAndSwap(key string, old, new []byte) bool {
+	swapped, _ := c.CompareAndSwapContext(context.Background(), key, old, new)
+	return swapped
+}
+
+func (c *Client) Incr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	return c.IncrContext(context.Background(), key, delta, ttlIfCreated)
+}
+
+func (c *Client) Decr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	return c.DecrContext(context.Background(), key, delta, ttlIfCreated)
+}
+
+func (c *Client) IncrByFloat(key string, delta float64, ttlIfCreated time.Duration) (float64, error) {
+	return c.IncrByFloatContext(context.Background(), key, delta, ttlIfCreated)
+}
+
+func (c *Client) Expire(key string, expireTime time.Duration) bool {
+	ok, _ := c.ExpireContext(context.Background(), key, expireTime)
+	return ok
+}
+
+func (c *Client) ExpireAt(key string, t time.Time) bool {
+	ok, _ := c.ExpireAtContext(context.Background(), key, t)
+	return ok
+}
+
+func (c *Client) Persist(key string) bool {
+	ok, _ := c.PersistContext(context.Background(), key)
+	return ok
+}
+
+func (c *Client) TTL(key string) (ttl time.Duration, exist bool) {
+	ttl, exist, _ = c.TTLContext(context.Background(), key)
+	return
+}
+
+func (c *Client) DeleteMatching(pattern string) int {
+	n, _ := c.DeleteMatchingContext(context.Background(), pattern)
+	return n
+}
+
+func (c *Client) DeleteMatchingRegexp(re *regexp.Regexp) int {
+	n, _ := c.DeleteMatchingRegexpContext(context.Background(), re)
+	return n
+}
+
+func (c *Client) Get(key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool) {
+	value, expireUnixNanosecondDateTime, exist, _ = c.GetContext(context.Background(), key)
+	return
+}
+
+func (c *Client) GetInterface(key string) (value interface{}, expireUnixNanosecondDateTime int64, exist bool) {
+	value, expireUnixNanosecondDateTime, exist, _ = c.GetInterfaceContext(context.Background(), key)
+	return
+}
+
+// GetOrLoad get the key, call the loader and set the value when missing,
+// the concurrent misses of one key in this client share one loader call
+func (c *Client) GetOrLoad(ctx context.Context, key string, loader gocache.Loader) (value []byte, expireUnixNanosecondDateTime int64, err error) {
+	value, expireUnixNanosecondDateTime, exist, err := c.GetContext(ctx, key)
+	if exist || err != nil {
+		return
+	}
+
+	v, expireUnixNanosecondDateTime, err := c.loadGroup.Do(ctx, key, func() (interface{}, int64, error) {
+		value, expireTime, err := loader(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// the value is returned even if it can not be set
+		expireUnixNanosecondDateTime := gocache.ExpireAfter(expireTime)
+		c.SetByExpireUnixNanosecondDateTimeContext(ctx, key, value, expireUnixNanosecondDateTime)
+		return value, expireUnixNanosecondDateTime, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return v.([]byte), expireUnixNanosecondDateTime, nil
+}
+
+// GetInterfaceOrLoad same as GetOrLoad, but the value is encoded by the codec
+func (c *Client) GetInterfaceOrLoad(ctx context.Context, key string, loader gocache.InterfaceLoader) (value interface{}, expireUnixNanosecondDateTime int64, err error) {
+	value, expireUnixNanosecondDateTime, exist, err := c.GetInterfaceContext(ctx, key)
+	if exist || err != nil {
+		return
+	}
+
+	return c.interfaceLoadGroup.Do(ctx, key, func() (interface{}, int64, error) {
+		value, expireTime, err := loader(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		expireUnixNanosecondDateTime := gocache.ExpireAfter(expireTime)
+		c.SetInterfaceByExpireUnixNanosecondDateTimeContext(ctx, key, value, expireUnixNanosecondDateTime)
+		return value, expireUnixNanosecondDateTime, nil
+	})
+}
+
+func (c *Client) GetOldestKey() (key string, expireUnixNanosecondDateTime int64, exist bool) {
+	key, expireUnixNanosecondDateTime, exist, _ = c.GetOldestKeyContext(context.Background())
+	return
+}
+
+func (c *Client) Size() int {
+	size, _ := c.SizeContext(context.Background())
+	return size
+}
+
+func (c *Client) Index(index int) (value []byte, expireUnixNanosecondDateTime int64, exist bool) {
+	value, expireUnixNanosecondDateTime, exist, _ = c.IndexContext(context.Background(), index)
+	return
+}
+
+func (c *Client) IndexInterface(index int) (value interface{}, expireUnixNanosecondDateTime int64, exist bool) {
+	value, expireUnixNanosecondDateTime, exist, _ = c.IndexInterfaceContext(context.Background(), index)
+	return
+}
+
+func (c *Client) KeyList() []string {
+	keys, _ := c.KeyListContext(context.Background())
+	return keys
+}
+
+func (c *Client) KeysMatching(pattern string) []string {
+	keys, _ := c.KeysMatchingContext(context.Background(), pattern)
+	return keys
+}
+
+func (c *Client) ScanRange(start, end string, limit int) []gocache.Entry {
+	entries, _ := c.ScanRangeContext(context.Background(), start, end, limit)
+	return entries
+}
+
+func (c *Client) ScanPrefix(prefix string, limit int) []gocache.Entry {
+	entries, _ := c.ScanPrefixContext(context.Background(), prefix, limit)
+	return entries
+}
+
+func (c *Client) MemoryUsage() int64 {
+	n, _ := c.MemoryUsageContext(context.Background())
+	return n
+}
+
+// OnEvict do nothing, the evictions happen in the server and are not sent to the clients, so f is never called,
+// register the callback on the cache of the server instead
+func (c *Client) OnEvict(f gocache.EvictFunc) {}
+
+func (c *Client) SaveSnapshot(w io.Writer) error {
+	return c.SaveSnapshotContext(context.Background(), w)
+}
+
+func (c *Client) LoadSnapshot(r io.Reader) error {
+	return c.LoadSnapshotContext(context.Background(), r)
+}
+
+func (c *Client) Stats() gocache.Stats {
+	stats, _ := c.StatsContext(context.Background())
+	return stats
+}
+
+func (c *Client) ResetStats() {
+	c.ResetStatsContext(context.Background())
+}
+
+// ShutDown close the connections, the server is not affected
+func (c *Client) ShutDown() {
+	c.pool.close()
+}
@@ -0,0 +1,335 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"github.com/hunterhug/gocache"
+	"github.com/hunterhug/gocache/resp"
+	"net"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, addr string, cache gocache.Cache) (*resp.Server, string) {
+	server := &resp.Server{Cache: cache, DefaultTTL: time.Minute}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go server.Serve(l)
+	return server, l.Addr().String()
+}
+
+func TestClient(t *testing.T) {
+	cache := gocache.New()
+	server, addr := newTestServer(t, "127.0.0.1:0", cache)
+	defer server.Close()
+	defer cache.ShutDown()
+
+	var c gocache.Cache = New(addr)
+	defer c.ShutDown()
+
+	gob.Register(map[string]int{})
+
+	c.Set("user:1", []byte("a hi"), 10*time.Second)
+	expire := time.Now().Add(time.Minute).UnixNano()
+	c.SetByExpireUnixNanosecondDateTime("user:2", []byte("b hi"), expire)
+	c.SetInterface("order:1", map[string]int{"a": 1}, 5*time.Second)
+
+	if value, _, exist := c.Get("user:1"); !exist || string(value) != "a hi" {
+		t.Fatalf("get should return a hi, but %q %v", value, exist)
+	}
+
+	if _, e, _ := c.Get("user:2"); e != expire {
+		t.Fatalf("expire should keep nanosecond %d, but %d", expire, e)
+	}
+
+	if _, e, _ := cache.Get("user:2"); e != expire {
+		t.Fatalf("server expire should be %d, but %d", expire, e)
+	}
+
+	if value, _, exist := c.GetInterface("order:1"); !exist || value.(map[string]int)["a"] != 1 {
+		t.Fatalf("get interface should return the decoded value, but %v", value)
+	}
+
+	if _, _, exist := c.Get("none"); exist {
+		t.Fatalf("none should not exist")
+	}
+
+	if key, _, _ := c.GetOldestKey(); key != "order:1" {
+		t.Fatalf("oldest key should be order:1, but %s", key)
+	}
+
+	if c.Size() != 3 || len(c.KeyList()) != 3 || c.MemoryUsage() != cache.MemoryUsage() {
+		t.Fatalf("size %d, keys %v, memory %d wrong", c.Size(), c.KeyList(), c.MemoryUsage())
+	}
+
+	if _, _, exist := c.Index(0); !exist {
+		t.Fatalf("index 0 should exist")
+	}
+
+	if entries := c.ScanPrefix("user:", 0); len(entries) != 2 || entries[1].Key != "user:2" || string(entries[1].Value) != "b hi" || entries[1].ExpireUnixNanosecondDateTime != expire {
+		t.Fatalf("scan prefix should return the user keys, but %v", entries)
+	}
+
+	if entries := c.ScanRange("", "user:", 1); len(entries) != 1 || entries[0].Key != "order:1" {
+		t.Fatalf("scan range should return order:1, but %v", entries)
+	}
+
+	if n := c.DeleteMatching("none:*"); n != 0 {
+		t.Fatalf("delete matching none:* should delete nothing, but %d", n)
+	}
+
+	if n := c.DeleteMatchingRegexp(regexp.MustCompile("^order:[0-9]+$")); n != 1 || c.Size() != 2 {
+		t.Fatalf("delete matching regexp should delete order:1, but %d", n)
+	}
+
+	c.SetWithTags("tagged:1", []byte("a"), time.Minute, "tag", "other")
+	c.SetWithTags("tagged:2", []byte("b"), time.Minute, "tag")
+	if n := c.InvalidateTag("tag"); n != 2 || c.Size() != 2 {
+		t.Fatalf("invalidate tag should delete the tagged keys, but %d", n)
+	}
+
+	if n, err := c.Incr("counter", 5, time.Minute); err != nil || n != 5 {
+		t.Fatalf("incr should create 5, but %d %v", n, err)
+	}
+
+	if n, _ := c.Decr("counter", 2, time.Minute); n != 3 {
+		t.Fatalf("decr should return 3, but %d", n)
+	}
+
+	if f, err := c.IncrByFloat("counter", 0.25, time.Minute); err != nil || f != 3.25 {
+		t.Fatalf("incr by float should return 3.25, but %v %v", f, err)
+	}
+
+	var numericError *gocache.NumericError
+	if _, err := c.Incr("counter", 1, time.Minute); !errors.As(err, &numericError) || numericError.Key != "counter" || !errors.Is(err, gocache.ErrNotInteger) {
+		t.Fatalf("incr float should return not integer, but %v", err)
+	}
+	c.Delete("counter")
+
+	if !c.SetIfAbsent("lock", []byte("a"), time.Minute) || c.SetIfAbsent("lock", []byte("b"), time.Minute) || c.SetIfPresent("none", []byte("a"), time.Minute) {
+		t.Fatalf("set if absent should only succeed once")
+	}
+
+	if old, exist := c.GetAndSet("lock", []byte("c"), time.Minute); !exist || string(old) != "a" {
+		t.Fatalf("get and set should return a, but %q %v", old, exist)
+	}
+
+	if !c.CompareAndSwap("lock", []byte("c"), []byte("d")) || c.CompareAndSwap("lock", []byte("c"), []byte("e")) || !c.SetIfPresent("lock", []byte("f"), time.Minute) {
+		t.Fatalf("compare and swap should only succeed once")
+	}
+
+	if !c.Persist("lock") || c.Persist("none") {
+		t.Fatalf("persist should only succeed for the exist key")
+	}
+
+	if ttl, exist := c.TTL("lock"); !exist || ttl != gocache.NoExpiration {
+		t.Fatalf("ttl should be no expiration, but %v %v", ttl, exist)
+	}
+
+	if !c.Expire("lock", time.Minute) {
+		t.Fatalf("expire should succeed")
+	}
+
+	if ttl, exist := c.TTL("lock"); !exist || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("ttl should be about a minute, but %v %v", ttl, exist)
+	}
+
+	if _, exist := c.TTL("none"); exist || c.ExpireAt("none", time.Now().Add(time.Minute)) {
+		t.Fatalf("not exist key should have no ttl")
+	}
+
+	c.SetSliding("session", []byte("a"), time.Minute, time.Hour)
+	if ttl, _ := c.TTL("session"); ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("sliding key should expire after a minute, but %v", ttl)
+	}
+	if keys := c.KeysMatching("sess*"); len(keys) != 1 || keys[0] != "session" {
+		t.Fatalf("keys matching should return session, but %v", keys)
+	}
+	c.Delete("session")
+
+	if old, exist := c.GetAndDelete("lock"); !exist || string(old) != "f" {
+		t.Fatalf("get and delete should return f, but %q %v", old, exist)
+	}
+
+	if _, exist := c.GetAndDelete("lock"); exist {
+		t.Fatalf("get and delete should return not exist after delete")
+	}
+
+	c.Delete("user:1")
+	if _, _, exist := c.Get("user:1"); exist {
+		t.Fatalf("user:1 should be deleted")
+	}
+
+	if stats := c.Stats(); stats.Sets != 13 || stats.Deletes != 7 || stats.Size != 1 {
+		t.Fatalf("stats wrong: %+v", stats)
+	}
+
+	c.ResetStats()
+	if stats := c.Stats(); stats.Sets != 0 {
+		t.Fatalf("stats should be reset: %+v", stats)
+	}
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Delete("user:2")
+	if err := c.LoadSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, e, exist := c.Get("user:2"); !exist || e != expire {
+		t.Fatalf("user:2 should be restored by snapshot")
+	}
+}
+
+func TestClientMulti(t *testing.T) {
+	cache := gocache.New()
+	server, addr := newTestServer(t, "127.0.0.1:0", cache)
+	defer server.Close()
+	defer cache.ShutDown()
+
+	c := New(addr)
+	defer c.ShutDown()
+
+	ctx := context.Background()
+	expire := time.Now().Add(time.Minute).UnixNano()
+	entries := []gocache.Entry{
+		{Key: "a", Value: []byte("1"), ExpireUnixNanosecondDateTime: expire},
+		{Key: "b", Value: []byte("2"), ExpireUnixNanosecondDateTime: expire},
+	}
+	if err := c.SetMulti(ctx, entries...); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetMulti(ctx, "a", "none", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 || string(got[0].Value) != "1" || got[1].Exist || string(got[2].Value) != "2" || got[2].ExpireUnixNanosecondDateTime != expire {
+		t.Fatalf("get multi wrong: %+v", got)
+	}
+
+	if err := c.DeleteMulti(ctx, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cache.Size() != 0 {
+		t.Fatalf("delete multi should delete all the keys, but %d", cache.Size())
+	}
+}
+
+func TestClientGetOrLoad(t *testing.T) {
+	cache := gocache.New()
+	server, addr := newTestServer(t, "127.0.0.1:0", cache)
+	defer server.Close()
+	defer cache.ShutDown()
+
+	c := New(addr)
+	defer c.ShutDown()
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) ([]byte, time.Duration, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return []byte("loaded"), time.Minute, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, _, err := c.GetOrLoad(context.Background(), "k", loader)
+			if err != nil || string(value) != "loaded" {
+				t.Errorf("get or load should return loaded, but %q %v", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("loader should be called once, but %d", calls.Load())
+	}
+
+	if value, _, _ := cache.Get("k"); string(value) != "loaded" {
+		t.Fatalf("loaded value should be set in server, but %q", value)
+	}
+
+	loadErr := errors.New("load fail")
+	if _, _, err := c.GetInterfaceOrLoad(context.Background(), "i", func(ctx context.Context) (interface{}, time.Duration, error) {
+		return nil, 0, loadErr
+	}); err != loadErr {
+		t.Fatalf("loader error should be returned, but %v", err)
+	}
+}
+
+func TestClientRetry(t *testing.T) {
+	cache := gocache.New()
+	server, addr := newTestServer(t, "127.0.0.1:0", cache)
+	defer cache.ShutDown()
+
+	c := New(addr)
+	defer c.ShutDown()
+
+	c.Set("a", []byte("1"), time.Minute)
+
+	// the idle connection is closed by the server, the get is retried by a new connection
+	server.Close()
+	server, _ = newTestServer(t, addr, cache)
+	defer server.Close()
+
+	if value, _, exist, err := c.GetContext(context.Background(), "a"); err != nil || !exist || string(value) != "1" {
+		t.Fatalf("get should be retried, but %q %v %v", value, exist, err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	// the server never reply
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	c := New(l.Addr().String(), WithReadTimeout(50*time.Millisecond), WithMaxRetries(0))
+	defer c.ShutDown()
+
+	start := time.Now()
+	if _, _, _, err := c.GetContext(context.Background(), "a"); err == nil || time.Since(start) > time.Second {
+		t.Fatalf("get should time out, but %v after %v", err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	c = New(l.Addr().String())
+	defer c.ShutDown()
+	if _, _, _, err := c.GetContext(ctx, "a"); err != context.DeadlineExceeded {
+		t.Fatalf("get should return deadline exceeded, but %v", err)
+	}
+
+	c.ShutDown()
+	if _, _, _, err := c.GetContext(context.Background(), "a"); err != ErrClosed {
+		t.Fatalf("get after shut down should return ErrClosed, but %v", err)
+	}
+}
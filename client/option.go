@@ -0,0 +1,112 @@
+package client
+
+import (
+	"github.com/hunterhug/gocache"
+	"time"
+)
+
+const (
+	defaultPoolSize     = 10
+	defaultDialTimeout  = time.Second
+	defaultReadTimeout  = 3 * time.Second
+	defaultWriteTimeout = 3 * time.Second
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = 10 * time.Millisecond
+)
+
+// Option config the client created by New
+type Option func(*options)
+
+type options struct {
+	// max number of idle connections kept in the pool
+	poolSize     int
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	// max number of retries of the idempotent commands when the network fail
+	maxRetries   int
+	retryBackoff time.Duration
+	// encode the value set by SetInterface
+	codec gocache.Codec
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		poolSize:     defaultPoolSize,
+		dialTimeout:  defaultDialTimeout,
+		readTimeout:  defaultReadTimeout,
+		writeTimeout: defaultWriteTimeout,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+		codec:        gocache.GobCodec{},
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithPoolSize set the max number of idle connections, default is 10
+func WithPoolSize(poolSize int) Option {
+	return func(o *options) {
+		if poolSize > 0 {
+			o.poolSize = poolSize
+		}
+	}
+}
+
+// WithDialTimeout set the timeout of dialing a new connection, default is 1 second
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.dialTimeout = timeout
+		}
+	}
+}
+
+// WithReadTimeout set the timeout of reading the replies, default is 3 seconds
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.readTimeout = timeout
+		}
+	}
+}
+
+// WithWriteTimeout set the timeout of writing the commands, default is 3 seconds
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		if timeout > 0 {
+			o.writeTimeout = timeout
+		}
+	}
+}
+
+// WithMaxRetries set the max number of retries of the idempotent commands, 0 means no retry, default is 2
+func WithMaxRetries(maxRetries int) Option {
+	return func(o *options) {
+		if maxRetries >= 0 {
+			o.maxRetries = maxRetries
+		}
+	}
+}
+
+// WithRetryBackoff set the time to wait before retry, it is doubled every retry, default is 10 milliseconds
+func WithRetryBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		if backoff >= 0 {
+			o.retryBackoff = backoff
+		}
+	}
+}
+
+// WithCodec set the codec to encode the value set by SetInterface, default is gocache.GobCodec
+func WithCodec(codec gocache.Codec) Option {
+	return func(o *options) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
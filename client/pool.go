@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"github.com/hunterhug/gocache/resp"
+	"net"
+	"sync"
+	"time"
+)
+
+var ErrClosed = errors.New("client: closed")
+
+type conn struct {
+	netConn net.Conn
+	r       *resp.Reader
+	w       *resp.Writer
+}
+
+// pool keep the idle connections, the connections are dialed when no one idle
+type pool struct {
+	addr   string
+	opts   options
+	locker sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+func (p *pool) get(ctx context.Context) (*conn, error) {
+	p.locker.Lock()
+	if p.closed {
+		p.locker.Unlock()
+		return nil, ErrClosed
+	}
+
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.locker.Unlock()
+		return c, nil
+	}
+	p.locker.Unlock()
+
+	dialer := net.Dialer{Timeout: p.opts.dialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &conn{netConn: netConn, r: resp.NewReader(netConn), w: resp.NewWriter(netConn)}, nil
+}
+
+// put return the connection to the pool, the broken one is closed
+func (p *pool) put(c *conn, broken bool) {
+	p.locker.Lock()
+	if broken || p.closed || len(p.idle) >= p.opts.poolSize {
+		p.locker.Unlock()
+		c.netConn.Close()
+		return
+	}
+
+	p.idle = append(p.idle, c)
+	p.locker.Unlock()
+}
+
+func (p *pool) close() {
+	p.locker.Lock()
+	defer p.locker.Unlock()
+	p.closed = true
+	for _, c := range p.idle {
+		c.netConn.Close()
+	}
+	p.idle = nil
+}
+
+// deadline return the earlier one of the ctx deadline and the timeout
+func deadline(ctx context.Context, timeout time.Duration) time.Time {
+	t := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(t) {
+		return d
+	}
+	return t
+}
+
+// roundTrip write the commands in one batch and read their replies,
+// the error replies are returned as resp.Error in the values, err is the network error
+func (p *pool) roundTrip(ctx context.Context, commands [][][]byte) (values []resp.Value, err error) {
+	c, err := p.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// interrupt the blocking io when ctx done
+	stop := context.AfterFunc(ctx, func() {
+		c.netConn.SetDeadline(time.Unix(1, 0))
+	})
+	defer func() {
+		// the deadline may be changed by the ctx, do not reuse the connection
+		broken := !stop() || err != nil
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		p.put(c, broken)
+	}()
+
+	c.netConn.SetWriteDeadline(deadline(ctx, p.opts.writeTimeout))
+	for _, args := range commands {
+		c.w.WriteCommand(args...)
+	}
+
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+
+	c.netConn.SetReadDeadline(deadline(ctx, p.opts.readTimeout))
+	values = make([]resp.Value, len(commands))
+	for i := range values {
+		if values[i], err = c.r.ReadValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// do send the commands in one round trip, retry with a new connection when the network fail and idempotent is true
+func (p *pool) do(ctx context.Context, idempotent bool, commands ...[][]byte) ([]resp.Value, error) {
+	backoff := p.opts.retryBackoff
+	for i := 0; ; i++ {
+		values, err := p.roundTrip(ctx, commands)
+		if err == nil || err == ErrClosed || ctx.Err() != nil || !idempotent || i >= p.opts.maxRetries {
+			return values, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
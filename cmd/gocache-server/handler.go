@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hunterhug/gocache"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errTTLOverflow = errors.New("ttl overflow")
+
+const (
+	headerExpire = "X-Gocache-Expire-Unix-Nanosecond"
+	headerTTL    = "X-Gocache-Ttl-Millisecond"
+)
+
+// handler serve the REST API over gocache.Cache:
+//
+//	PUT    /keys/{key}?ttl=10s  set the body as value, ttl is a duration or seconds
+//	GET    /keys/{key}          get the value, the expire time is in the headers
+//	DELETE /keys/{key}          delete the key
+//	GET    /keys?prefix=        list the keys sorted, filtered by prefix
+//	GET    /oldest              the key will expire first
+//	GET    /size                number of keys
+type handler struct {
+	cache      gocache.Cache
+	maxBody    int64
+	defaultTTL time.Duration
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/keys/"):
+		key := strings.TrimPrefix(r.URL.Path, "/keys/")
+		if key == "" {
+			http.Error(w, "empty key", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			h.put(w, r, key)
+		case http.MethodGet, http.MethodHead:
+			h.get(w, r, key)
+		case http.MethodDelete:
+			h.cache.Delete(key)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			methodNotAllowed(w, "GET, HEAD, PUT, DELETE")
+		}
+	case r.URL.Path == "/keys":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, "GET")
+			return
+		}
+		h.keys(w, r)
+	case r.URL.Path == "/oldest":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, "GET")
+			return
+		}
+		h.oldest(w, r)
+	case r.URL.Path == "/size":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, "GET")
+			return
+		}
+		writeJSON(w, map[string]int{"size": h.cache.Size()})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// parseTTL parse duration like 1m30s, or seconds like 90
+func parseTTL(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if seconds > math.MaxInt64/int64(time.Second) || seconds < math.MinInt64/int64(time.Second) {
+			return 0, errTTLOverflow
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+func (h *handler) put(w http.ResponseWriter, r *http.Request, key string) {
+	ttl := h.defaultTTL
+	if s := r.URL.Query().Get("ttl"); s != "" {
+		d, err := parseTTL(s)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return
+		}
+		ttl = d
+	}
+
+	value, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBody))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expireUnixNanosecondDateTime := time.Now().Add(ttl).UnixNano()
+	h.cache.SetByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+	setExpireHeader(w, expireUnixNanosecondDateTime)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request, key string) {
+	value, expireUnixNanosecondDateTime, exist := h.cache.Get(key)
+	if !exist {
+		http.NotFound(w, r)
+		return
+	}
+
+	setExpireHeader(w, expireUnixNanosecondDateTime)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(value)))
+	if r.Method == http.MethodGet {
+		w.Write(value)
+	}
+}
+
+func (h *handler) keys(w http.ResponseWriter, r *http.Request) {
+	// the scan is sorted and skip the expired keys not removed yet
+	entries := h.cache.ScanPrefix(r.URL.Query().Get("prefix"), 0)
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+
+	writeJSON(w, keys)
+}
+
+func (h *handler) oldest(w http.ResponseWriter, r *http.Request) {
+	key, expireUnixNanosecondDateTime, exist := h.cache.GetOldestKey()
+	if !exist {
+		http.NotFound(w, r)
+		return
+	}
+
+	setExpireHeader(w, expireUnixNanosecondDateTime)
+	writeJSON(w, map[string]interface{}{
+		"key":                          key,
+		"expireUnixNanosecondDateTime": expireUnixNanosecondDateTime,
+	})
+}
+
+func setExpireHeader(w http.ResponseWriter, expireUnixNanosecondDateTime int64) {
+	ttl := time.Until(time.Unix(0, expireUnixNanosecondDateTime))
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	w.Header().Set(headerExpire, strconv.FormatInt(expireUnixNanosecondDateTime, 10))
+	w.Header().Set(headerTTL, strconv.FormatInt(ttl.Milliseconds(), 10))
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
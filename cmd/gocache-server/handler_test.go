@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/hunterhug/gocache"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	cache := gocache.New()
+	defer cache.ShutDown()
+
+	server := httptest.NewServer(&handler{cache: cache, maxBody: 8, defaultTTL: time.Minute})
+	defer server.Close()
+
+	do := func(method, path, body string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := do(http.MethodPut, "/keys/user:1?ttl=10", "a hi"); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("put should return 204, but %d", resp.StatusCode)
+	}
+	do(http.MethodPut, "/keys/user:2?ttl=1s", "b hi")
+	do(http.MethodPut, "/keys/order:1", "c hi")
+
+	if resp := do(http.MethodPut, "/keys/big", "123456789"); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("put big body should return 413, but %d", resp.StatusCode)
+	}
+
+	for _, ttl := range []string{"x", "9223372036854775807", "-9223372036854775807"} {
+		if resp := do(http.MethodPut, "/keys/a?ttl="+ttl, "a"); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("put invalid ttl %s should return 400, but %d", ttl, resp.StatusCode)
+		}
+	}
+
+	resp := do(http.MethodGet, "/keys/user:1", "")
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "a hi" || resp.Header.Get(headerTTL) == "" || resp.Header.Get(headerExpire) == "" {
+		t.Fatalf("get should return a hi with ttl, but %s %v", body, resp.Header)
+	}
+
+	// expired but not removed yet
+	do(http.MethodPut, "/keys/user:3?ttl=1ms", "d hi")
+	time.Sleep(2 * time.Millisecond)
+
+	var keys []string
+	json.NewDecoder(do(http.MethodGet, "/keys?prefix=user:", "").Body).Decode(&keys)
+	if strings.Join(keys, ",") != "user:1,user:2" {
+		t.Fatalf("keys should be user:1,user:2, but %v", keys)
+	}
+	do(http.MethodDelete, "/keys/user:3", "")
+
+	var oldest struct {
+		Key string `json:"key"`
+	}
+	json.NewDecoder(do(http.MethodGet, "/oldest", "").Body).Decode(&oldest)
+	if oldest.Key != "user:2" {
+		t.Fatalf("oldest should be user:2, but %s", oldest.Key)
+	}
+
+	do(http.MethodDelete, "/keys/user:1", "")
+	if resp := do(http.MethodGet, "/keys/user:1", ""); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get deleted key should return 404, but %d", resp.StatusCode)
+	}
+
+	var size struct {
+		Size int `json:"size"`
+	}
+	json.NewDecoder(do(http.MethodGet, "/size", "").Body).Decode(&size)
+	if size.Size != 2 {
+		t.Fatalf("size should be 2, but %d", size.Size)
+	}
+}
@@ -0,0 +1,98 @@
+// Command gocache-server share one gocache over the network
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/hunterhug/gocache"
+	"github.com/hunterhug/gocache/memcached"
+	"github.com/hunterhug/gocache/metrics"
+	"github.com/hunterhug/gocache/resp"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	var (
+		addr            = flag.String("addr", ":8080", "http listen address")
+		respAddr        = flag.String("resp-addr", "", "redis protocol listen address, empty means disabled")
+		memcachedAddr   = flag.String("memcached-addr", "", "memcached protocol listen address, empty means disabled")
+		maxBody         = flag.Int64("max-body", 1<<20, "max bytes of the request body")
+		defaultTTL      = flag.Duration("default-ttl", time.Hour, "ttl used when the request not set it")
+		shards          = flag.Int("shards", 0, "number of shards, 0 means not sharded")
+		maxEntries      = flag.Int("max-entries", 0, "max number of keys, 0 means no limit")
+		maxMemory       = flag.Int64("max-memory", 0, "max bytes of keys and values, 0 means no limit")
+		shutdownTimeout = flag.Duration("shutdown-timeout", 10*time.Second, "time to wait for the requests when shut down")
+	)
+	flag.Parse()
+
+	opts := []gocache.Option{gocache.WithMaxEntries(*maxEntries), gocache.WithMaxMemory(*maxMemory)}
+	var cache gocache.Cache
+	if *shards > 0 {
+		cache = gocache.NewSharded(*shards, opts...)
+	} else {
+		cache = gocache.NewWithOptions(opts...)
+	}
+
+	metricsHandler := metrics.NewHandler()
+	metricsHandler.Register("default", cache)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", &handler{cache: cache, maxBody: *maxBody, defaultTTL: *defaultTTL})
+	mux.Handle("/metrics", metricsHandler)
+	server := &http.Server{Addr: *addr, Handler: mux}
+
+	go func() {
+		log.Printf("gocache-server listen on %s", *addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	var respServer *resp.Server
+	if *respAddr != "" {
+		respServer = &resp.Server{Cache: cache, DefaultTTL: *defaultTTL}
+		go func() {
+			log.Printf("gocache-server redis protocol listen on %s", *respAddr)
+			if err := respServer.ListenAndServe(*respAddr); err != nil && err != resp.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	var memcachedServer *memcached.Server
+	if *memcachedAddr != "" {
+		memcachedServer = &memcached.Server{Cache: cache, MaxItemSize: int(*maxBody)}
+		go func() {
+			log.Printf("gocache-server memcached protocol listen on %s", *memcachedAddr)
+			if err := memcachedServer.ListenAndServe(*memcachedAddr); err != nil && err != memcached.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Printf("gocache-server shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("shut down http server: %v", err)
+	}
+
+	if respServer != nil {
+		respServer.Close()
+	}
+
+	if memcachedServer != nil {
+		memcachedServer.Close()
+	}
+
+	cache.ShutDown()
+}
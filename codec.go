@@ -0,0 +1,34 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// Codec encode and decode the value set by SetInterface when it leave the memory, such as snapshot
+type Codec interface {
+	Marshal(value interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// GobCodec the default codec, the concrete type of the value must be registered by gob.Register,
+// except the basic types
+type GobCodec struct{}
+
+func (GobCodec) Marshal(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,145 @@
+package gocache
+
+import (
+	"bytes"
+	"github.com/hunterhug/gocache/algorithm"
+	"time"
+)
+
+// lookup return the live item of the key, the expired key is removed, must hold the lock
+func (c *cache) lookup(key string) *cacheItem {
+	treeMapValue, exist := c.treeMap.Get(key)
+	if !exist {
+		return nil
+	}
+
+	heapValue := treeMapValue.(*algorithm.HeapValue)
+	item := heapValue.Extra.(*cacheItem)
+	if item.IsExpire() {
+		c.remove(heapValue, EvictReasonExpired)
+		c.stats.lazyExpirations.Add(1)
+		return nil
+	}
+
+	return item
+}
+
+// setIf set the key in one critical section when cond of the live old item is true, old is nil when the key not exist
+func (c *cache) setIf(key string, value []byte, expireTime time.Duration, cond func(old *cacheItem) bool) (old *cacheItem, set bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return nil, false
+	}
+
+	old = c.lookup(key)
+	if !cond(old) {
+		return old, false
+	}
+
+	item := cacheItem{
+		RawByte: value,
+		cost:    int64(len(key) + len(value)),
+	}
+	c.put(key, item, ExpireAfter(expireTime))
+	return old, true
+}
+
+// SetIfAbsent set the key only if it not exist or expired, return true if set
+func (c *cache) SetIfAbsent(key string, value []byte, expireTime time.Duration) bool {
+	_, set := c.setIf(key, value, expireTime, func(old *cacheItem) bool {
+		return old == nil
+	})
+	return set
+}
+
+// SetIfPresent set the key only if it exist, return true if set
+func (c *cache) SetIfPresent(key string, value []byte, expireTime time.Duration) bool {
+	_, set := c.setIf(key, value, expireTime, func(old *cacheItem) bool {
+		return old != nil
+	})
+	return set
+}
+
+// GetAndSet set the key and return the old value, the value set by SetInterface is returned as nil
+func (c *cache) GetAndSet(key string, value []byte, expireTime time.Duration) (old []byte, exist bool) {
+	item, _ := c.setIf(key, value, expireTime, func(old *cacheItem) bool {
+		return true
+	})
+	if item == nil {
+		return nil, false
+	}
+
+	return item.RawByte, true
+}
+
+// GetAndDelete delete the key and return the old value in one critical section, the value set by SetInterface is returned as nil,
+// exist is false when the key not exist or expired
+func (c *cache) GetAndDelete(key string) (old []byte, exist bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	treeMapValue, exist := c.treeMap.Get(key)
+	if !exist {
+		return nil, false
+	}
+
+	heapValue := treeMapValue.(*algorithm.HeapValue)
+	item := heapValue.Extra.(*cacheItem)
+	if item.IsExpire() {
+		c.remove(heapValue, EvictReasonExpired)
+		c.stats.lazyExpirations.Add(1)
+		return nil, false
+	}
+
+	c.remove(heapValue, EvictReasonDeleted)
+	c.stats.deletes.Add(1)
+	return item.RawByte, true
+}
+
+// CompareAndSwap replace the value of the key with new only if the current value equal to old byte by byte,
+// the expire time, the sliding expiration and the tags are kept, return true if swapped
+func (c *cache) CompareAndSwap(key string, old, new []byte) bool {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return false
+	}
+
+	item := c.lookup(key)
+	if item == nil || item.RawByte == nil || !bytes.Equal(item.RawByte, old) {
+		return false
+	}
+
+	value := cacheItem{
+		RawByte: new,
+		cost:    item.cost - int64(len(item.RawByte)) + int64(len(new)),
+		tags:    item.tags,
+	}
+	value.sliding, value.maxExpireUnixNanosecondDateTime = item.sliding, item.maxExpireUnixNanosecondDateTime
+	c.put(key, value, item.expireUnixNanosecondDateTime)
+	return true
+}
+
+func (c *shardedCache) SetIfAbsent(key string, value []byte, expireTime time.Duration) bool {
+	return c.shard(key).SetIfAbsent(key, value, expireTime)
+}
+
+func (c *shardedCache) SetIfPresent(key string, value []byte, expireTime time.Duration) bool {
+	return c.shard(key).SetIfPresent(key, value, expireTime)
+}
+
+func (c *shardedCache) GetAndDelete(key string) (old []byte, exist bool) {
+	return c.shard(key).GetAndDelete(key)
+}
+
+func (c *shardedCache) GetAndSet(key string, value []byte, expireTime time.Duration) (old []byte, exist bool) {
+	return c.shard(key).GetAndSet(key, value, expireTime)
+}
+
+func (c *shardedCache) CompareAndSwap(key string, old, new []byte) bool {
+	return c.shard(key).CompareAndSwap(key, old, new)
+}
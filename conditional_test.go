@@ -0,0 +1,100 @@
+package gocache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSetIf(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	if c.SetIfPresent("key", []byte("a"), time.Minute) {
+		t.Fatalf("set if present should fail when the key not exist")
+	}
+
+	if !c.SetIfAbsent("key", []byte("a"), time.Minute) || c.SetIfAbsent("key", []byte("b"), time.Minute) {
+		t.Fatalf("set if absent should only succeed once")
+	}
+
+	if !c.SetIfPresent("key", []byte("c"), time.Minute) {
+		t.Fatalf("set if present should succeed when the key exist")
+	}
+
+	if old, exist := c.GetAndSet("key", []byte("d"), time.Minute); !exist || string(old) != "c" {
+		t.Fatalf("get and set should return c, but %q %v", old, exist)
+	}
+
+	if old, exist := c.GetAndSet("new", []byte("e"), time.Minute); exist || old != nil {
+		t.Fatalf("get and set should return not exist, but %q %v", old, exist)
+	}
+
+	c.Set("deleted", []byte("a"), time.Minute)
+	if old, exist := c.GetAndDelete("deleted"); !exist || string(old) != "a" {
+		t.Fatalf("get and delete should return a, but %q %v", old, exist)
+	}
+
+	if _, exist := c.GetAndDelete("deleted"); exist {
+		t.Fatalf("get and delete should return not exist after delete")
+	}
+
+	// expired key is absent
+	c.Set("expired", []byte("a"), -time.Second)
+	if _, exist := c.GetAndDelete("expired"); exist {
+		t.Fatalf("get and delete should return not exist for the expired key")
+	}
+	c.Set("expired", []byte("a"), -time.Second)
+	if c.SetIfPresent("expired", []byte("b"), time.Minute) || !c.SetIfAbsent("expired", []byte("b"), time.Minute) {
+		t.Fatalf("expired key should be absent")
+	}
+
+	if value, _, _ := c.Get("key"); string(value) != "d" {
+		t.Fatalf("value should be d, but %q", value)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	c.SetWithTags("key", []byte("a"), time.Minute, "tag")
+	_, expire, _ := c.Get("key")
+	if c.CompareAndSwap("key", []byte("b"), []byte("c")) || c.CompareAndSwap("none", nil, []byte("c")) {
+		t.Fatalf("compare and swap should fail when not equal or not exist")
+	}
+
+	if !c.CompareAndSwap("key", []byte("a"), []byte("b")) {
+		t.Fatalf("compare and swap should succeed")
+	}
+
+	if value, e, _ := c.Get("key"); string(value) != "b" || e != expire || c.InvalidateTag("tag") != 1 {
+		t.Fatalf("compare and swap should keep the expire time and the tags, but %q %d", value, e)
+	}
+
+	// only one of the concurrent swaps from the same value succeed
+	for name, c := range map[string]Cache{"cache": New(), "sharded": NewSharded(4)} {
+		c.Set("leader", []byte("none"), time.Minute)
+		var wins, absent atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if c.CompareAndSwap("leader", []byte("none"), []byte("me")) {
+					wins.Add(1)
+				}
+				if c.SetIfAbsent("lock", []byte("me"), time.Minute) {
+					absent.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if wins.Load() != 1 || absent.Load() != 1 {
+			t.Fatalf("%s: only one should win, but %d %d", name, wins.Load(), absent.Load())
+		}
+		c.ShutDown()
+	}
+}
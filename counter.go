@@ -0,0 +1,150 @@
+package gocache
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrNotInteger = errors.New("gocache: value is not an integer")
+	ErrNotFloat   = errors.New("gocache: value is not a float")
+	ErrOverflow   = errors.New("gocache: increment or decrement would overflow")
+)
+
+// NumericError returned by the counters when the value of the key can not be incremented,
+// Err is ErrNotInteger, ErrNotFloat or ErrOverflow
+type NumericError struct {
+	Key string
+	Err error
+}
+
+func (e *NumericError) Error() string {
+	return e.Err.Error() + ": " + strconv.Quote(e.Key)
+}
+
+func (e *NumericError) Unwrap() error {
+	return e.Err
+}
+
+// Incr add delta to the integer value of the key in one critical section and return the new value,
+// the value is stored as decimal bytes, the existing expire time is kept,
+// the missing or expired key is created from 0 and expire after ttlIfCreated, never expire if ttlIfCreated is 0
+func (c *cache) Incr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	var n int64
+	err := c.update(key, ttlIfCreated, func(old []byte, exist bool) ([]byte, error) {
+		if exist {
+			var err error
+			n, err = strconv.ParseInt(string(old), 10, 64)
+			if err != nil {
+				return nil, ErrNotInteger
+			}
+		}
+
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return nil, ErrOverflow
+		}
+
+		n += delta
+		return strconv.AppendInt(nil, n, 10), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Decr subtract delta from the integer value of the key, see Incr
+func (c *cache) Decr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, &NumericError{Key: key, Err: ErrOverflow}
+	}
+
+	return c.Incr(key, -delta, ttlIfCreated)
+}
+
+// IncrByFloat add delta to the float value of the key, see Incr
+func (c *cache) IncrByFloat(key string, delta float64, ttlIfCreated time.Duration) (float64, error) {
+	var f float64
+	err := c.update(key, ttlIfCreated, func(old []byte, exist bool) ([]byte, error) {
+		if exist {
+			var err error
+			f, err = strconv.ParseFloat(string(old), 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, ErrNotFloat
+			}
+		}
+
+		f += delta
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, ErrOverflow
+		}
+
+		return strconv.AppendFloat(nil, f, 'f', -1, 64), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return f, nil
+}
+
+// update replace the value of the key by f in one critical section, the expire time, the sliding expiration and the tags are kept,
+// the key is created with ttlIfCreated when missing or expired, 0 means never expire, the value set by SetInterface is not numeric
+func (c *cache) update(key string, ttlIfCreated time.Duration, f func(old []byte, exist bool) ([]byte, error)) error {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return nil
+	}
+
+	old := c.lookup(key)
+	if old != nil && old.RawByte == nil {
+		return &NumericError{Key: key, Err: ErrNotInteger}
+	}
+
+	var value []byte
+	var err error
+	if old != nil {
+		value, err = f(old.RawByte, true)
+	} else {
+		value, err = f(nil, false)
+	}
+	if err != nil {
+		return &NumericError{Key: key, Err: err}
+	}
+
+	item := cacheItem{
+		RawByte: value,
+		cost:    int64(len(key) + len(value)),
+	}
+
+	expireUnixNanosecondDateTime := NeverExpire
+	if ttlIfCreated != 0 {
+		expireUnixNanosecondDateTime = ExpireAfter(ttlIfCreated)
+	}
+
+	if old != nil {
+		expireUnixNanosecondDateTime = old.expireUnixNanosecondDateTime
+		item.tags = old.tags
+		item.sliding, item.maxExpireUnixNanosecondDateTime = old.sliding, old.maxExpireUnixNanosecondDateTime
+		item.cost = old.cost - int64(len(old.RawByte)) + int64(len(value))
+	}
+
+	c.put(key, item, expireUnixNanosecondDateTime)
+	return nil
+}
+
+func (c *shardedCache) Incr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	return c.shard(key).Incr(key, delta, ttlIfCreated)
+}
+
+func (c *shardedCache) Decr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	return c.shard(key).Decr(key, delta, ttlIfCreated)
+}
+
+func (c *shardedCache) IncrByFloat(key string, delta float64, ttlIfCreated time.Duration) (float64, error) {
+	return c.shard(key).IncrByFloat(key, delta, ttlIfCreated)
+}
@@ -0,0 +1,117 @@
+package gocache
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIncr(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	if n, err := c.Incr("counter", 5, time.Minute); err != nil || n != 5 {
+		t.Fatalf("incr missing key should create 5, but %d %v", n, err)
+	}
+
+	_, expire, _ := c.Get("counter")
+	time.Sleep(time.Millisecond)
+	if n, err := c.Decr("counter", 7, time.Hour); err != nil || n != -2 {
+		t.Fatalf("decr should return -2, but %d %v", n, err)
+	}
+
+	if value, e, _ := c.Get("counter"); string(value) != "-2" || e != expire {
+		t.Fatalf("value should be -2 and keep the expire time, but %q %d", value, e)
+	}
+
+	c.Set("text", []byte("abc"), time.Minute)
+	c.SetInterface("interface", 1, time.Minute)
+	for _, key := range []string{"text", "interface"} {
+		_, err := c.Incr(key, 1, time.Minute)
+		var numericError *NumericError
+		if !errors.As(err, &numericError) || numericError.Key != key || !errors.Is(err, ErrNotInteger) {
+			t.Fatalf("incr %s should return not integer, but %v", key, err)
+		}
+	}
+
+	c.Set("max", []byte("9223372036854775807"), time.Minute)
+	if _, err := c.Incr("max", 1, time.Minute); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("incr max should overflow, but %v", err)
+	}
+
+	if _, err := c.Decr("max", math.MinInt64, time.Minute); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("decr min int64 should overflow, but %v", err)
+	}
+
+	// expired key is created again
+	c.Set("expired", []byte("100"), -time.Second)
+	if n, _ := c.Incr("expired", 1, time.Minute); n != 1 {
+		t.Fatalf("incr expired key should create 1, but %d", n)
+	}
+
+	// 0 ttl never expire
+	if n, err := c.Incr("forever", 1, 0); err != nil || n != 1 {
+		t.Fatalf("incr with 0 ttl should create 1, but %d %v", n, err)
+	}
+
+	if ttl, exist := c.TTL("forever"); !exist || ttl != NoExpiration {
+		t.Fatalf("incr with 0 ttl should never expire, but %v %v", ttl, exist)
+	}
+
+	// the tags are kept
+	c.SetWithTags("tagged", []byte("1"), time.Minute, "tag")
+	c.Incr("tagged", 1, time.Minute)
+	if c.InvalidateTag("tag") != 1 {
+		t.Fatalf("incr should keep the tags")
+	}
+}
+
+func TestIncrByFloat(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	c.Set("float", []byte("10"), time.Minute)
+	if f, err := c.IncrByFloat("float", 0.5, time.Minute); err != nil || f != 10.5 {
+		t.Fatalf("incr by float should return 10.5, but %v %v", f, err)
+	}
+
+	if value, _, _ := c.Get("float"); string(value) != "10.5" {
+		t.Fatalf("value should be 10.5, but %q", value)
+	}
+
+	if _, err := c.Incr("float", 1, time.Minute); !errors.Is(err, ErrNotInteger) {
+		t.Fatalf("incr float should return not integer, but %v", err)
+	}
+
+	if _, err := c.IncrByFloat("float", math.Inf(1), time.Minute); !errors.Is(err, ErrOverflow) {
+		t.Fatalf("incr by inf should overflow, but %v", err)
+	}
+
+	c.Set("nan", []byte("NaN"), time.Minute)
+	if _, err := c.IncrByFloat("nan", 1, time.Minute); !errors.Is(err, ErrNotFloat) {
+		t.Fatalf("incr NaN should return not float, but %v", err)
+	}
+}
+
+func TestIncrConcurrent(t *testing.T) {
+	for name, c := range map[string]Cache{"cache": New(), "sharded": NewSharded(4)} {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					c.Incr("counter", 1, time.Minute)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if value, _, _ := c.Get("counter"); string(value) != "1000" {
+			t.Fatalf("%s: counter should be 1000, but %q", name, value)
+		}
+		c.ShutDown()
+	}
+}
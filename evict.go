@@ -0,0 +1,82 @@
+package gocache
+
+// EvictReason why the key is removed from cache
+type EvictReason int
+
+const (
+	// EvictReasonExpired the key is expired, removed by the janitor or when get it
+	EvictReasonExpired EvictReason = iota + 1
+	// EvictReasonDeleted the key is removed by Delete
+	EvictReasonDeleted
+	// EvictReasonReplaced the value is overwritten by Set
+	EvictReasonReplaced
+	// EvictReasonCapacity the key is evicted because of the max entries or the max memory
+	EvictReasonCapacity
+	// EvictReasonShutDown the cache is shut down
+	EvictReasonShutDown
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonDeleted:
+		return "deleted"
+	case EvictReasonReplaced:
+		return "replaced"
+	case EvictReasonCapacity:
+		return "capacity"
+	case EvictReasonShutDown:
+		return "shutdown"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictFunc called when the key is removed from cache,
+// value is []byte when set by Set, otherwise is the value set by SetInterface
+type EvictFunc func(key string, value interface{}, reason EvictReason)
+
+type evictedItem struct {
+	key    string
+	value  interface{}
+	reason EvictReason
+}
+
+// addEvicted record the removed key, must hold the lock
+func (c *cache) addEvicted(key string, item *cacheItem, reason EvictReason) {
+	if c.onEvict == nil {
+		return
+	}
+
+	c.evicted = append(c.evicted, evictedItem{
+		key:    key,
+		value:  item.value(),
+		reason: reason,
+	})
+}
+
+// unlock release the lock, then call the evict func outside the lock,
+// so the evict func can call back into the cache
+func (c *cache) unlock() {
+	evicted := c.evicted
+	onEvict := c.onEvict
+	aofErrors := c.aofErrors
+	c.evicted = nil
+	c.aofErrors = nil
+	c.locker.Unlock()
+
+	for _, e := range evicted {
+		onEvict(e.key, e.value, e.reason)
+	}
+
+	for _, err := range aofErrors {
+		c.aof.onError(err)
+	}
+}
+
+func (c *cache) OnEvict(f EvictFunc) {
+	c.locker.Lock()
+	defer c.unlock()
+	c.onEvict = f
+}
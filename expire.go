@@ -0,0 +1,185 @@
+package gocache
+
+import (
+	"github.com/hunterhug/gocache/algorithm"
+	"math"
+	"time"
+)
+
+const (
+	// NeverExpire the expire unix nanosecond of the keys never expire,
+	// they are kept outside the heap so the janitor never see them
+	NeverExpire int64 = math.MaxInt64
+	// NoExpiration pass as the expire time to keep the key forever
+	NoExpiration time.Duration = math.MaxInt64
+)
+
+// ExpireAfter return the expire unix nanosecond after expireTime from now, NeverExpire if it overflow
+func ExpireAfter(expireTime time.Duration) int64 {
+	now := time.Now().UnixNano()
+	if expireTime > 0 && int64(expireTime) >= NeverExpire-now {
+		return NeverExpire
+	}
+
+	return now + int64(expireTime/time.Nanosecond)
+}
+
+// track put the heap value into the heap, or into the persistent list when it never expire,
+// the index of the heap value is its position in the list, must hold the lock
+func (c *cache) track(heapValue *algorithm.HeapValue) {
+	if heapValue.Value != NeverExpire {
+		c.minHeap.Push(heapValue)
+		return
+	}
+
+	heapValue.Index = len(c.persistent)
+	c.persistent = append(c.persistent, heapValue)
+}
+
+// untrack remove the heap value from the heap or the persistent list, must hold the lock
+func (c *cache) untrack(heapValue *algorithm.HeapValue) {
+	if heapValue.Value != NeverExpire {
+		c.minHeap.PopIndex(heapValue.Index)
+		return
+	}
+
+	last := len(c.persistent) - 1
+	c.persistent[heapValue.Index] = c.persistent[last]
+	c.persistent[heapValue.Index].Index = heapValue.Index
+	c.persistent[last] = nil
+	c.persistent = c.persistent[:last]
+}
+
+// setExpire change the expire time of the tracked heap value in O(log n), must hold the lock
+func (c *cache) setExpire(heapValue *algorithm.HeapValue, expireUnixNanosecondDateTime int64) {
+	heapValue.Extra.(*cacheItem).expireUnixNanosecondDateTime = expireUnixNanosecondDateTime
+	if (heapValue.Value == NeverExpire) != (expireUnixNanosecondDateTime == NeverExpire) {
+		c.untrack(heapValue)
+		heapValue.Value = expireUnixNanosecondDateTime
+		c.track(heapValue)
+		return
+	}
+
+	if heapValue.Value != expireUnixNanosecondDateTime {
+		heapValue.Value = expireUnixNanosecondDateTime
+		c.minHeap.Fix(heapValue.Index)
+	}
+}
+
+// size the number of the keys include the expired ones not removed yet, must hold the lock
+func (c *cache) size() int {
+	return c.minHeap.Size() + len(c.persistent)
+}
+
+// at return the index-th key, the keys in the heap come first, then the keys never expire, must hold the lock
+func (c *cache) at(index int) *algorithm.HeapValue {
+	if index < 0 {
+		return nil
+	}
+
+	if size := c.minHeap.Size(); index >= size {
+		index -= size
+		if index >= len(c.persistent) {
+			return nil
+		}
+		return c.persistent[index]
+	}
+
+	return c.minHeap.Get(index)
+}
+
+// Expire set the key expire after expireTime, NoExpiration keep it forever,
+// the key is deleted if expireTime is not positive, return false if the key not exist
+func (c *cache) Expire(key string, expireTime time.Duration) bool {
+	return c.expireAt(key, ExpireAfter(expireTime))
+}
+
+// ExpireAt set the key expire at t, the key is deleted if t is in the past, return false if the key not exist
+func (c *cache) ExpireAt(key string, t time.Time) bool {
+	return c.expireAt(key, t.UnixNano())
+}
+
+// Persist keep the key forever, return false if the key not exist or already never expire
+func (c *cache) Persist(key string) bool {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return false
+	}
+
+	item := c.lookup(key)
+	if item == nil || item.expireUnixNanosecondDateTime == NeverExpire {
+		return false
+	}
+
+	c.expireItem(key, item, NeverExpire)
+	return true
+}
+
+// TTL return the remaining time to live of the key, NoExpiration if it never expire
+func (c *cache) TTL(key string) (ttl time.Duration, exist bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	item := c.lookup(key)
+	if item == nil {
+		return 0, false
+	}
+
+	if item.expireUnixNanosecondDateTime == NeverExpire {
+		return NoExpiration, true
+	}
+
+	return time.Duration(item.expireUnixNanosecondDateTime - time.Now().UnixNano()), true
+}
+
+func (c *cache) expireAt(key string, expireUnixNanosecondDateTime int64) bool {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return false
+	}
+
+	item := c.lookup(key)
+	if item == nil {
+		return false
+	}
+
+	c.expireItem(key, item, expireUnixNanosecondDateTime)
+	return true
+}
+
+// expireItem change the expire time of the live item, delete it if the time is in the past,
+// the sliding item become expire at the absolute time, must hold the lock
+func (c *cache) expireItem(key string, item *cacheItem, expireUnixNanosecondDateTime int64) {
+	treeMapValue, _ := c.treeMap.Get(key)
+	heapValue := treeMapValue.(*algorithm.HeapValue)
+	if expireUnixNanosecondDateTime <= time.Now().UnixNano() {
+		c.remove(heapValue, EvictReasonDeleted)
+		c.stats.deletes.Add(1)
+		return
+	}
+
+	item.sliding, item.maxExpireUnixNanosecondDateTime = 0, 0
+	c.setExpire(heapValue, expireUnixNanosecondDateTime)
+	c.appendAOFSet(key, item)
+}
+
+func (c *shardedCache) Expire(key string, expireTime time.Duration) bool {
+	return c.shard(key).Expire(key, expireTime)
+}
+
+func (c *shardedCache) ExpireAt(key string, t time.Time) bool {
+	return c.shard(key).ExpireAt(key, t)
+}
+
+func (c *shardedCache) Persist(key string) bool {
+	return c.shard(key).Persist(key)
+}
+
+func (c *shardedCache) TTL(key string) (ttl time.Duration, exist bool) {
+	return c.shard(key).TTL(key)
+}
@@ -0,0 +1,133 @@
+package gocache
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExpire(t *testing.T) {
+	c := NewWithOptions(WithJanitor(false)).(*cache)
+	defer c.ShutDown()
+
+	c.Set("forever", []byte("a"), NoExpiration)
+	c.Set("minute", []byte("b"), time.Minute)
+	c.Set("second", []byte("c"), time.Second)
+	if c.minHeap.Size() != 2 || len(c.persistent) != 1 || c.Size() != 3 {
+		t.Fatalf("never expire key should be kept outside the heap, but heap %d persistent %d", c.minHeap.Size(), len(c.persistent))
+	}
+
+	if ttl, exist := c.TTL("forever"); !exist || ttl != NoExpiration {
+		t.Fatalf("ttl should be no expiration, but %v %v", ttl, exist)
+	}
+
+	if ttl, exist := c.TTL("minute"); !exist || ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("ttl should be about a minute, but %v %v", ttl, exist)
+	}
+
+	if _, exist := c.TTL("none"); exist || c.Expire("none", time.Minute) || c.Persist("none") {
+		t.Fatalf("not exist key should not be changed")
+	}
+
+	// the heap is fixed in place
+	if !c.Expire("minute", time.Millisecond) {
+		t.Fatalf("expire should succeed")
+	}
+	if key, _, _ := c.GetOldestKey(); key != "minute" {
+		t.Fatalf("oldest key should be minute, but %s", key)
+	}
+
+	if !c.Persist("second") || c.Persist("second") || c.minHeap.Size() != 1 || len(c.persistent) != 2 {
+		t.Fatalf("persist should move the key out of the heap once")
+	}
+
+	if !c.Expire("forever", time.Millisecond) || c.minHeap.Size() != 2 || len(c.persistent) != 1 {
+		t.Fatalf("expire should move the key into the heap")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	c.cleanOlder()
+	if c.Size() != 1 || c.minHeap.Size() != 0 {
+		t.Fatalf("only the persisted key should be left, but %v", c.KeyList())
+	}
+
+	if key, _, exist := c.GetOldestKey(); exist {
+		t.Fatalf("never expire key should not be the oldest, but %s", key)
+	}
+
+	if value, expire, exist := c.Get("second"); !exist || expire != NeverExpire || !bytes.Equal(value, []byte("c")) {
+		t.Fatalf("persisted key should keep the value, but %q %d %v", value, expire, exist)
+	}
+
+	if !c.ExpireAt("second", time.Now().Add(-time.Second)) || c.Size() != 0 || c.Stats().Deletes != 1 {
+		t.Fatalf("expire at the past time should delete the key")
+	}
+
+	// replace the key between the heap and the persistent list
+	c.Set("key", []byte("a"), NoExpiration)
+	c.Set("key", []byte("b"), time.Minute)
+	c.Set("key", []byte("c"), NoExpiration)
+	if c.minHeap.Size() != 0 || len(c.persistent) != 1 || c.Size() != 1 {
+		t.Fatalf("replaced key should be tracked once, but heap %d persistent %d", c.minHeap.Size(), len(c.persistent))
+	}
+
+	if ExpireAfter(NoExpiration) != NeverExpire || ExpireAfter(NoExpiration-time.Second) != NeverExpire {
+		t.Fatalf("overflowed expire time should never expire")
+	}
+}
+
+func TestExpireEvict(t *testing.T) {
+	c := NewWithOptions(WithJanitor(false), WithMaxMemory(4)).(*cache)
+	defer c.ShutDown()
+
+	c.Set("a", []byte("a"), NoExpiration)
+	c.Set("b", []byte("b"), NoExpiration)
+	c.Set("c", []byte("c"), time.Minute)
+	if _, _, exist := c.Get("c"); exist {
+		t.Fatalf("expiring key should be evicted before the keys never expire")
+	}
+
+	c.Set("d", []byte("d"), NoExpiration)
+	if _, _, exist := c.Get("a"); exist || c.Size() != 2 {
+		t.Fatalf("least recently used key never expire should be evicted, but %v", c.KeyList())
+	}
+
+	s := NewSharded(4)
+	defer s.ShutDown()
+	s.Set("key", []byte("a"), time.Minute)
+	if !s.Persist("key") {
+		t.Fatalf("sharded persist should succeed")
+	}
+
+	if ttl, _ := s.TTL("key"); ttl != NoExpiration {
+		t.Fatalf("sharded ttl should be no expiration, but %v", ttl)
+	}
+}
+
+// TestExpireGet run with -race, the expire time is changed in place while the values are read
+func TestExpireGet(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	c.Set("a", []byte("a"), time.Minute)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			c.Expire("a", time.Duration(i+1)*time.Minute)
+			c.Persist("a")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			if _, expire, exist := c.Get("a"); !exist || expire <= time.Now().UnixNano() {
+				t.Errorf("a should not expire, but %d %v", expire, exist)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
@@ -1,3 +1,3 @@
 module github.com/hunterhug/gocache
 
-go 1.16
+go 1.21
@@ -0,0 +1,77 @@
+// Package loadgroup coalesce the concurrent loads of the same key into one call, shared by the cache and the client
+package loadgroup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Group coalesce the concurrent loads of the same key into one call, the zero value is ready to use
+type Group struct {
+	locker sync.Mutex
+	calls  map[string]*call
+}
+
+type call struct {
+	done                         chan struct{}
+	value                        interface{}
+	expireUnixNanosecondDateTime int64
+	err                          error
+	// the ctx of the caller who call fn is done, the waiters should not share its error
+	canceled bool
+}
+
+// Do call fn once for the concurrent callers of the same key, the waiters return early when their ctx done,
+// the waiters load again when the call fail because the ctx of the caller who call fn is done
+func (g *Group) Do(ctx context.Context, key string, fn func() (interface{}, int64, error)) (value interface{}, expireUnixNanosecondDateTime int64, err error) {
+	g.locker.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	for {
+		c, ok := g.calls[key]
+		if !ok {
+			break
+		}
+
+		g.locker.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		}
+
+		if !c.canceled || ctx.Err() != nil {
+			return c.value, c.expireUnixNanosecondDateTime, c.err
+		}
+
+		g.locker.Lock()
+	}
+
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.locker.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			c.err = fmt.Errorf("gocache: loader of key %s panic: %v", key, r)
+			g.finish(key, c)
+			panic(r)
+		}
+
+		g.finish(key, c)
+	}()
+
+	c.value, c.expireUnixNanosecondDateTime, c.err = fn()
+	c.canceled = c.err != nil && ctx.Err() != nil
+	return c.value, c.expireUnixNanosecondDateTime, c.err
+}
+
+func (g *Group) finish(key string, c *call) {
+	g.locker.Lock()
+	delete(g.calls, key)
+	g.locker.Unlock()
+	close(c.done)
+}
@@ -0,0 +1,346 @@
+// Package invalidation broadcast the changed keys to the other nodes, so they drop their local copies
+package invalidation
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/hunterhug/gocache"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op the change of the key
+type Op byte
+
+const (
+	OpSet Op = iota + 1
+	OpDelete
+	// OpDeleteMatching the key of the event is the glob pattern
+	OpDeleteMatching
+	// OpDeleteRegexp the key of the event is the regular expression
+	OpDeleteRegexp
+	// OpInvalidateTag the key of the event is the tag
+	OpInvalidateTag
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	case OpDeleteMatching:
+		return "delete matching"
+	case OpDeleteRegexp:
+		return "delete regexp"
+	case OpInvalidateTag:
+		return "invalidate tag"
+	}
+	return "unknown"
+}
+
+// Event the key changed in the origin node
+type Event struct {
+	Op     Op
+	Key    string
+	Origin string
+	// Seq increase in each origin, it start from the unix nanosecond so a restarted node keep increasing
+	Seq uint64
+}
+
+const eventVersion = 1
+
+var ErrEventFormat = errors.New("invalidation: event format invalid")
+
+// encode format: version byte | op byte | seq uint64 | origin length uvarint | origin | key length uvarint | key
+func (e Event) encode() []byte {
+	buf := make([]byte, 0, 2+8+2*binary.MaxVarintLen64+len(e.Origin)+len(e.Key))
+	buf = append(buf, eventVersion, byte(e.Op))
+	buf = binary.BigEndian.AppendUint64(buf, e.Seq)
+	buf = binary.AppendUvarint(buf, uint64(len(e.Origin)))
+	buf = append(buf, e.Origin...)
+	buf = binary.AppendUvarint(buf, uint64(len(e.Key)))
+	return append(buf, e.Key...)
+}
+
+func decodeEvent(msg []byte) (e Event, err error) {
+	if len(msg) < 10 || msg[0] != eventVersion {
+		return e, ErrEventFormat
+	}
+
+	e.Op = Op(msg[1])
+	if e.Op < OpSet || e.Op > OpInvalidateTag {
+		return e, ErrEventFormat
+	}
+
+	e.Seq = binary.BigEndian.Uint64(msg[2:10])
+	msg = msg[10:]
+	fields := make([]string, 2)
+	for i := range fields {
+		n, size := binary.Uvarint(msg)
+		if size <= 0 || n > uint64(len(msg)-size) {
+			return e, ErrEventFormat
+		}
+
+		fields[i] = string(msg[size : size+int(n)])
+		msg = msg[size+int(n):]
+	}
+
+	if len(msg) != 0 {
+		return e, ErrEventFormat
+	}
+
+	e.Origin, e.Key = fields[0], fields[1]
+	return e, nil
+}
+
+// Transport deliver the messages between the nodes
+type Transport interface {
+	// Broadcast send the message to all the nodes, the sender may receive it too
+	Broadcast(msg []byte) error
+	// Listen call f for every received message, block until the transport is closed
+	Listen(f func(msg []byte)) error
+	Close() error
+}
+
+// Bus wrap the local cache, the Set, Delete, DeleteMatching and InvalidateTag are broadcast to the other nodes,
+// the keys changed by the other nodes are deleted from the local cache, the own echoes are ignored,
+// the duplicated or reordered events are applied as they come, because applying an event only delete the local copies.
+// GetOrLoad, GetInterfaceOrLoad and the refresh ahead reload fill the local cache from the source of truth,
+// they do not change the data, so they are not broadcast
+type Bus struct {
+	gocache.Cache
+	nodeID    string
+	transport Transport
+	seq       atomic.Uint64
+
+	locker  sync.Mutex
+	onEvent func(Event)
+	onError func(error)
+	done    chan struct{}
+}
+
+// New new the bus of the node, nodeID must be unique in the cluster, it start to listen the transport
+func New(nodeID string, cache gocache.Cache, transport Transport) *Bus {
+	b := &Bus{
+		Cache:     cache,
+		nodeID:    nodeID,
+		transport: transport,
+		done:      make(chan struct{}),
+	}
+	b.seq.Store(uint64(time.Now().UnixNano()))
+
+	go func() {
+		defer close(b.done)
+		b.transport.Listen(b.receive)
+	}()
+	return b
+}
+
+// OnEvent set the func called after the event from the other nodes is applied
+func (b *Bus) OnEvent(f func(Event)) {
+	b.locker.Lock()
+	defer b.locker.Unlock()
+	b.onEvent = f
+}
+
+// OnError set the func called when the event can not be broadcast
+func (b *Bus) OnError(f func(error)) {
+	b.locker.Lock()
+	defer b.locker.Unlock()
+	b.onError = f
+}
+
+// Publish broadcast the change of the key
+func (b *Bus) Publish(op Op, key string) error {
+	e := Event{Op: op, Key: key, Origin: b.nodeID, Seq: b.seq.Add(1)}
+	err := b.transport.Broadcast(e.encode())
+	if err != nil {
+		b.locker.Lock()
+		onError := b.onError
+		b.locker.Unlock()
+		if onError != nil {
+			onError(err)
+		}
+	}
+	return err
+}
+
+func (b *Bus) receive(msg []byte) {
+	e, err := decodeEvent(msg)
+	if err != nil || e.Origin == b.nodeID {
+		return
+	}
+
+	b.locker.Lock()
+	onEvent := b.onEvent
+	b.locker.Unlock()
+
+	switch e.Op {
+	case OpDeleteMatching:
+		b.Cache.DeleteMatching(e.Key)
+	case OpDeleteRegexp:
+		if re, err := regexp.Compile(e.Key); err == nil {
+			b.Cache.DeleteMatchingRegexp(re)
+		}
+	case OpInvalidateTag:
+		b.Cache.InvalidateTag(e.Key)
+	default:
+		b.Cache.Delete(e.Key)
+	}
+
+	if onEvent != nil {
+		onEvent(e)
+	}
+}
+
+func (b *Bus) Set(key string, value []byte, expireTime time.Duration) {
+	b.Cache.Set(key, value, expireTime)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetInterface(key string, value interface{}, expireTime time.Duration) {
+	b.Cache.SetInterface(key, value, expireTime)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetByExpireUnixNanosecondDateTime(key string, value []byte, expireUnixNanosecondDateTime int64) {
+	b.Cache.SetByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetInterfaceByExpireUnixNanosecondDateTime(key string, value interface{}, expireUnixNanosecondDateTime int64) {
+	b.Cache.SetInterfaceByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetSliding(key string, value []byte, slidingExpiration, maxLifetime time.Duration) {
+	b.Cache.SetSliding(key, value, slidingExpiration, maxLifetime)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetInterfaceSliding(key string, value interface{}, slidingExpiration, maxLifetime time.Duration) {
+	b.Cache.SetInterfaceSliding(key, value, slidingExpiration, maxLifetime)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetWithTags(key string, value []byte, expireTime time.Duration, tags ...string) {
+	b.Cache.SetWithTags(key, value, expireTime, tags...)
+	b.Publish(OpSet, key)
+}
+
+func (b *Bus) SetIfAbsent(key string, value []byte, expireTime time.Duration) bool {
+	set := b.Cache.SetIfAbsent(key, value, expireTime)
+	if set {
+		b.Publish(OpSet, key)
+	}
+	return set
+}
+
+func (b *Bus) SetIfPresent(key string, value []byte, expireTime time.Duration) bool {
+	set := b.Cache.SetIfPresent(key, value, expireTime)
+	if set {
+		b.Publish(OpSet, key)
+	}
+	return set
+}
+
+func (b *Bus) GetAndSet(key string, value []byte, expireTime time.Duration) (old []byte, exist bool) {
+	old, exist = b.Cache.GetAndSet(key, value, expireTime)
+	b.Publish(OpSet, key)
+	return
+}
+
+func (b *Bus) GetAndDelete(key string) (old []byte, exist bool) {
+	old, exist = b.Cache.GetAndDelete(key)
+	b.Publish(OpDelete, key)
+	return
+}
+
+func (b *Bus) CompareAndSwap(key string, old, new []byte) bool {
+	swapped := b.Cache.CompareAndSwap(key, old, new)
+	if swapped {
+		b.Publish(OpSet, key)
+	}
+	return swapped
+}
+
+func (b *Bus) Expire(key string, expireTime time.Duration) bool {
+	ok := b.Cache.Expire(key, expireTime)
+	if ok {
+		b.Publish(OpSet, key)
+	}
+	return ok
+}
+
+func (b *Bus) ExpireAt(key string, t time.Time) bool {
+	ok := b.Cache.ExpireAt(key, t)
+	if ok {
+		b.Publish(OpSet, key)
+	}
+	return ok
+}
+
+func (b *Bus) Persist(key string) bool {
+	ok := b.Cache.Persist(key)
+	if ok {
+		b.Publish(OpSet, key)
+	}
+	return ok
+}
+
+func (b *Bus) Incr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	n, err := b.Cache.Incr(key, delta, ttlIfCreated)
+	if err == nil {
+		b.Publish(OpSet, key)
+	}
+	return n, err
+}
+
+func (b *Bus) Decr(key string, delta int64, ttlIfCreated time.Duration) (int64, error) {
+	n, err := b.Cache.Decr(key, delta, ttlIfCreated)
+	if err == nil {
+		b.Publish(OpSet, key)
+	}
+	return n, err
+}
+
+func (b *Bus) IncrByFloat(key string, delta float64, ttlIfCreated time.Duration) (float64, error) {
+	f, err := b.Cache.IncrByFloat(key, delta, ttlIfCreated)
+	if err == nil {
+		b.Publish(OpSet, key)
+	}
+	return f, err
+}
+
+func (b *Bus) Delete(key string) {
+	b.Cache.Delete(key)
+	b.Publish(OpDelete, key)
+}
+
+func (b *Bus) DeleteMatching(pattern string) int {
+	n := b.Cache.DeleteMatching(pattern)
+	b.Publish(OpDeleteMatching, pattern)
+	return n
+}
+
+func (b *Bus) DeleteMatchingRegexp(re *regexp.Regexp) int {
+	n := b.Cache.DeleteMatchingRegexp(re)
+	b.Publish(OpDeleteRegexp, re.String())
+	return n
+}
+
+func (b *Bus) InvalidateTag(tag string) int {
+	n := b.Cache.InvalidateTag(tag)
+	b.Publish(OpInvalidateTag, tag)
+	return n
+}
+
+// ShutDown close the transport and shut down the local cache
+func (b *Bus) ShutDown() {
+	b.transport.Close()
+	<-b.done
+	b.Cache.ShutDown()
+}
@@ -0,0 +1,171 @@
+package invalidation
+
+import (
+	"github.com/hunterhug/gocache"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// waitEvent wait the event applied by the bus
+func waitEvent(t *testing.T, events chan Event) Event {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(3 * time.Second):
+		t.Fatalf("event not received")
+	}
+	return Event{}
+}
+
+func newTestBus(t *testing.T, nodeID string, transport Transport) (*Bus, chan Event) {
+	b := New(nodeID, gocache.New(), transport)
+	events := make(chan Event, 16)
+	b.OnEvent(func(e Event) {
+		events <- e
+	})
+	t.Cleanup(b.ShutDown)
+	return b, events
+}
+
+func TestEventEncode(t *testing.T) {
+	e := Event{Op: OpDelete, Key: "key", Origin: "node-a", Seq: 42}
+	decoded, err := decodeEvent(e.encode())
+	if err != nil || decoded != e {
+		t.Fatalf("event should be decoded as %v, but %v %v", e, decoded, err)
+	}
+
+	msg := e.encode()
+	for _, bad := range [][]byte{msg[:5], msg[:len(msg)-1], append(msg, 0), {2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}} {
+		if _, err := decodeEvent(bad); err != ErrEventFormat {
+			t.Fatalf("%v should be invalid, but %v", bad, err)
+		}
+	}
+}
+
+func TestBus(t *testing.T) {
+	network := NewMemoryNetwork()
+	a, aEvents := newTestBus(t, "a", network.NewTransport())
+	b, bEvents := newTestBus(t, "b", network.NewTransport())
+
+	b.Cache.Set("key", []byte("old"), time.Minute)
+	a.Set("key", []byte("new"), time.Minute)
+
+	e := waitEvent(t, bEvents)
+	if e.Op != OpSet || e.Key != "key" || e.Origin != "a" {
+		t.Fatalf("b should receive the set event from a, but %v", e)
+	}
+
+	if _, _, exist := b.Get("key"); exist {
+		t.Fatalf("b should drop the old value")
+	}
+
+	// own echo ignored
+	if value, _, _ := a.Get("key"); string(value) != "new" {
+		t.Fatalf("a should keep its value, but %q", value)
+	}
+
+	b.Cache.Set("counter", []byte("1"), time.Minute)
+	a.Incr("counter", 1, time.Minute)
+	if e := waitEvent(t, bEvents); e.Op != OpSet || e.Key != "counter" {
+		t.Fatalf("b should receive the set event of the counter, but %v", e)
+	}
+
+	if a.CompareAndSwap("counter", []byte("none"), []byte("value")) || !a.CompareAndSwap("counter", []byte("1"), []byte("2")) {
+		t.Fatalf("a should swap the counter once")
+	}
+
+	if e := waitEvent(t, bEvents); e.Op != OpSet || e.Key != "counter" {
+		t.Fatalf("b should receive the set event of the swap, but %v", e)
+	}
+
+	if a.Persist("none") || !a.Persist("counter") {
+		t.Fatalf("a should persist the counter")
+	}
+
+	if e := waitEvent(t, bEvents); e.Op != OpSet || e.Key != "counter" {
+		t.Fatalf("b should receive the set event of the persist, but %v", e)
+	}
+
+	a.Cache.Set("other", []byte("value"), time.Minute)
+	b.Delete("other")
+	if e := waitEvent(t, aEvents); e.Op != OpDelete || e.Key != "other" || e.Origin != "b" {
+		t.Fatalf("a should receive the delete event from b, but %v", e)
+	}
+
+	if _, _, exist := a.Get("other"); exist {
+		t.Fatalf("a should drop the deleted key")
+	}
+
+	a.Cache.Set("tenant:42:user:1", []byte("value"), time.Minute)
+	a.Cache.Set("tenant:43:user:1", []byte("value"), time.Minute)
+	b.DeleteMatching("tenant:42:*")
+	waitEvent(t, aEvents)
+	b.DeleteMatchingRegexp(regexp.MustCompile("^tenant:43:"))
+	if e := waitEvent(t, aEvents); e.Op != OpDeleteRegexp || len(a.ScanPrefix("tenant:", 0)) != 0 {
+		t.Fatalf("a should delete the matched keys, but %v, keys %v", e, a.KeyList())
+	}
+
+	a.SetWithTags("tagged", []byte("value"), time.Minute, "tag")
+	waitEvent(t, bEvents)
+	b.Cache.SetWithTags("tagged", []byte("value"), time.Minute, "tag")
+	a.InvalidateTag("tag")
+	if e := waitEvent(t, bEvents); e.Op != OpInvalidateTag || b.Size() != 0 {
+		t.Fatalf("b should delete the tagged keys, but %v, keys %v", e, b.KeyList())
+	}
+
+	select {
+	case e := <-bEvents:
+		t.Fatalf("b should ignore its own event, but %v", e)
+	default:
+	}
+}
+
+func TestBusReordered(t *testing.T) {
+	network := NewMemoryNetwork()
+	b, events := newTestBus(t, "b", network.NewTransport())
+
+	// the later event arrive first, the earlier one is still applied
+	b.Cache.Set("key:1", []byte("value"), time.Minute)
+	b.Cache.Set("key:2", []byte("value"), time.Minute)
+	b.receive(Event{Op: OpDelete, Key: "key:2", Origin: "a", Seq: 11}.encode())
+	waitEvent(t, events)
+	b.receive(Event{Op: OpSet, Key: "key:1", Origin: "a", Seq: 10}.encode())
+	if e := waitEvent(t, events); e.Seq != 10 || b.Size() != 0 {
+		t.Fatalf("reordered event should be applied, but %v, keys %v", e, b.KeyList())
+	}
+
+	// duplicated event only delete the local copy again
+	b.Cache.Set("key:1", []byte("value"), time.Minute)
+	b.receive(Event{Op: OpSet, Key: "key:1", Origin: "a", Seq: 10}.encode())
+	if e := waitEvent(t, events); e.Seq != 10 || b.Size() != 0 {
+		t.Fatalf("duplicated event should delete the key, but %v, keys %v", e, b.KeyList())
+	}
+}
+
+func TestUDPTransport(t *testing.T) {
+	ta, err := NewUDPTransport("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tb, err := NewUDPTransport("127.0.0.1:0", ta.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ta.peers = append(ta.peers, tb.Addr().(*net.UDPAddr))
+
+	a, aEvents := newTestBus(t, "a", ta)
+	b, _ := newTestBus(t, "b", tb)
+
+	a.Cache.Set("key", []byte("value"), time.Minute)
+	b.Delete("key")
+	if e := waitEvent(t, aEvents); e.Op != OpDelete || e.Key != "key" || e.Origin != "b" {
+		t.Fatalf("a should receive the delete event from b, but %v", e)
+	}
+
+	if _, _, exist := a.Get("key"); exist {
+		t.Fatalf("a should drop the deleted key")
+	}
+}
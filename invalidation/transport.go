@@ -0,0 +1,163 @@
+package invalidation
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+var ErrTransportClosed = errors.New("invalidation: transport closed")
+
+// max size of the udp payload
+const maxDatagramSize = 65507
+
+// UDPTransport send the messages to the peer list by udp, a peer can be a multicast group address,
+// if the listen address is a multicast group the transport join it
+type UDPTransport struct {
+	conn  *net.UDPConn
+	peers []*net.UDPAddr
+}
+
+// NewUDPTransport listen on listenAddr such as :7946 or 239.0.0.1:7946, and send to the peers such as 10.0.0.2:7946 or 239.0.0.1:7946
+func NewUDPTransport(listenAddr string, peers ...string) (*UDPTransport, error) {
+	addr, err := net.ResolveUDPAddr("udp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	t := new(UDPTransport)
+	for _, peer := range peers {
+		peerAddr, err := net.ResolveUDPAddr("udp", peer)
+		if err != nil {
+			return nil, err
+		}
+		t.peers = append(t.peers, peerAddr)
+	}
+
+	if addr.IP != nil && addr.IP.IsMulticast() {
+		t.conn, err = net.ListenMulticastUDP("udp", nil, addr)
+	} else {
+		t.conn, err = net.ListenUDP("udp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Addr return the listening address
+func (t *UDPTransport) Addr() net.Addr {
+	return t.conn.LocalAddr()
+}
+
+// Broadcast send the message to every peer, the first error is returned after all sent
+func (t *UDPTransport) Broadcast(msg []byte) error {
+	if len(msg) > maxDatagramSize {
+		return ErrEventFormat
+	}
+
+	var firstErr error
+	for _, peer := range t.peers {
+		if _, err := t.conn.WriteToUDP(msg, peer); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t *UDPTransport) Listen(f func(msg []byte)) error {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, _, err := t.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return ErrTransportClosed
+			}
+			return err
+		}
+
+		f(buf[:n])
+	}
+}
+
+func (t *UDPTransport) Close() error {
+	return t.conn.Close()
+}
+
+// MemoryNetwork connect the memory transports in one process, used by the tests
+type MemoryNetwork struct {
+	locker     sync.Mutex
+	transports []*MemoryTransport
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return new(MemoryNetwork)
+}
+
+// NewTransport new a transport joining the network
+func (n *MemoryNetwork) NewTransport() *MemoryTransport {
+	n.locker.Lock()
+	defer n.locker.Unlock()
+	t := &MemoryTransport{network: n, messages: make(chan []byte, 1024), done: make(chan struct{})}
+	n.transports = append(n.transports, t)
+	return t
+}
+
+func (n *MemoryNetwork) remove(t *MemoryTransport) {
+	n.locker.Lock()
+	defer n.locker.Unlock()
+	for i, transport := range n.transports {
+		if transport == t {
+			n.transports = append(n.transports[:i], n.transports[i+1:]...)
+			return
+		}
+	}
+}
+
+// MemoryTransport deliver the messages to all the transports of the network include itself, like multicast
+type MemoryTransport struct {
+	network   *MemoryNetwork
+	messages  chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (t *MemoryTransport) Broadcast(msg []byte) error {
+	select {
+	case <-t.done:
+		return ErrTransportClosed
+	default:
+	}
+
+	t.network.locker.Lock()
+	transports := append([]*MemoryTransport(nil), t.network.transports...)
+	t.network.locker.Unlock()
+
+	for _, transport := range transports {
+		select {
+		case transport.messages <- append([]byte(nil), msg...):
+		case <-transport.done:
+		}
+	}
+	return nil
+}
+
+func (t *MemoryTransport) Listen(f func(msg []byte)) error {
+	for {
+		select {
+		case msg := <-t.messages:
+			f(msg)
+		case <-t.done:
+			return ErrTransportClosed
+		}
+	}
+}
+
+func (t *MemoryTransport) Close() error {
+	t.closeOnce.Do(func() {
+		t.network.remove(t)
+		close(t.done)
+	})
+	return nil
+}
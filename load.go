@@ -0,0 +1,167 @@
+package gocache
+
+import (
+	"context"
+	"github.com/hunterhug/gocache/algorithm"
+	"time"
+)
+
+// Loader load the value when the key is missing in cache, the value will be set with expireTime
+type Loader func(ctx context.Context) (value []byte, expireTime time.Duration, err error)
+
+// InterfaceLoader same as Loader, but load the value set by SetInterface
+type InterfaceLoader func(ctx context.Context) (value interface{}, expireTime time.Duration, err error)
+
+func (c *cache) GetOrLoad(ctx context.Context, key string, loader Loader) (value []byte, expireUnixNanosecondDateTime int64, err error) {
+	value, expireUnixNanosecondDateTime, exist := c.Get(key)
+	if exist {
+		return
+	}
+
+	v, expireUnixNanosecondDateTime, err := c.loadGroup.Do(ctx, key, func() (interface{}, int64, error) {
+		// the key may be loaded by the caller just finished
+		if item, exist := c.peek(key); exist {
+			return item.RawByte, item.expireUnixNanosecondDateTime, nil
+		}
+
+		reload := c.byteReloadFunc(key, loader)
+		item, expireTime, err := reload(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		expireUnixNanosecondDateTime := ExpireAfter(expireTime)
+		c.setByExpireDateTime(key, item, expireUnixNanosecondDateTime)
+		return item.RawByte, expireUnixNanosecondDateTime, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return v.([]byte), expireUnixNanosecondDateTime, nil
+}
+
+func (c *cache) GetInterfaceOrLoad(ctx context.Context, key string, loader InterfaceLoader) (value interface{}, expireUnixNanosecondDateTime int64, err error) {
+	value, expireUnixNanosecondDateTime, exist := c.GetInterface(key)
+	if exist {
+		return
+	}
+
+	value, expireUnixNanosecondDateTime, err = c.interfaceLoadGroup.Do(ctx, key, func() (interface{}, int64, error) {
+		// the key may be loaded by the caller just finished
+		if item, exist := c.peek(key); exist {
+			return item.Raw, item.expireUnixNanosecondDateTime, nil
+		}
+
+		reload := c.interfaceReloadFunc(key, loader)
+		item, expireTime, err := reload(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		expireUnixNanosecondDateTime := ExpireAfter(expireTime)
+		c.setByExpireDateTime(key, item, expireUnixNanosecondDateTime)
+		return item.Raw, expireUnixNanosecondDateTime, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return
+}
+
+// peek return a copy of the live item of the key without counting the hits and misses or touching the recently used list
+func (c *cache) peek(key string) (item cacheItem, exist bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	if live := c.lookup(key); live != nil {
+		return *live, true
+	}
+
+	return
+}
+
+// reloadFunc call the loader and wrap the value into cache item
+type reloadFunc func(ctx context.Context) (item cacheItem, expireTime time.Duration, err error)
+
+func (c *cache) byteReloadFunc(key string, loader Loader) reloadFunc {
+	var reload reloadFunc
+	reload = func(ctx context.Context) (item cacheItem, expireTime time.Duration, err error) {
+		value, expireTime, err := loader(ctx)
+		if err != nil {
+			return
+		}
+
+		item = cacheItem{
+			RawByte: value,
+			cost:    int64(len(key) + len(value)),
+			reload:  reload,
+		}
+		return
+	}
+
+	return reload
+}
+
+func (c *cache) interfaceReloadFunc(key string, loader InterfaceLoader) reloadFunc {
+	var reload reloadFunc
+	reload = func(ctx context.Context) (item cacheItem, expireTime time.Duration, err error) {
+		value, expireTime, err := loader(ctx)
+		if err != nil {
+			return
+		}
+
+		item = cacheItem{
+			Raw:         value,
+			isInterface: true,
+			cost:        c.interfaceCost(key, value),
+			reload:      reload,
+		}
+		return
+	}
+
+	return reload
+}
+
+// refreshAhead start a background reload when the item is going to expire within the window, must hold the lock
+func (c *cache) refreshAhead(key string, item *cacheItem) {
+	if c.opts.refreshAheadWindow <= 0 || item.reload == nil || item.refreshing {
+		return
+	}
+
+	if item.expireUnixNanosecondDateTime-time.Now().UnixNano() > int64(c.opts.refreshAheadWindow) {
+		return
+	}
+
+	item.refreshing = true
+	go c.refresh(key, item)
+}
+
+// refresh reload the item, then replace it if it is not changed during reloading
+func (c *cache) refresh(key string, old *cacheItem) {
+	item, expireTime, err := old.reload(context.Background())
+	expireUnixNanosecondDateTime := ExpireAfter(expireTime)
+
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	treeMapValue, exist := c.treeMap.Get(key)
+	if !exist || treeMapValue.(*algorithm.HeapValue).Extra != old {
+		return
+	}
+
+	if err != nil {
+		// try again next get
+		old.refreshing = false
+		return
+	}
+
+	c.put(key, item, expireUnixNanosecondDateTime)
+}
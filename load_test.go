@@ -0,0 +1,160 @@
+package gocache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	var calls int32
+	loader := func(ctx context.Context) ([]byte, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return []byte("loaded"), 10 * time.Second, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _, err := c.GetOrLoad(context.Background(), "a", loader)
+			if err != nil || string(v) != "loaded" {
+				t.Errorf("get or load should return loaded, but %s %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("loader should be called once, but %d", calls)
+	}
+
+	if v, _, _ := c.Get("a"); string(v) != "loaded" {
+		t.Fatalf("a should be loaded, but %s", v)
+	}
+}
+
+func TestGetOrLoadCanceled(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	started := make(chan struct{})
+	var calls int32
+	loader := func(ctx context.Context) ([]byte, time.Duration, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, 0, ctx.Err()
+		}
+		return []byte("loaded"), time.Minute, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, _, err := c.GetOrLoad(ctx, "a", loader)
+		leader <- err
+	}()
+	<-started
+
+	waiter := make(chan []byte)
+	go func() {
+		v, _, _ := c.GetOrLoad(context.Background(), "a", loader)
+		waiter <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-leader; err != context.Canceled {
+		t.Fatalf("leader should be canceled, but %v", err)
+	}
+
+	// the waiter with the live ctx load again
+	if v := <-waiter; string(v) != "loaded" || calls != 2 {
+		t.Fatalf("waiter should load again, but %q %d", v, calls)
+	}
+
+	// each caller miss once, the loads not count the hits and misses
+	if stats := c.Stats(); stats.Hits != 0 || stats.Misses != 2 {
+		t.Fatalf("loads should not count the stats, but %+v", stats)
+	}
+}
+
+func TestGetInterfaceOrLoadError(t *testing.T) {
+	c := NewSharded(4)
+	defer c.ShutDown()
+
+	loadErr := errors.New("load error")
+	var calls int32
+	loader := func(ctx context.Context) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return nil, 0, loadErr
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := c.GetInterfaceOrLoad(context.Background(), "a", loader); err != loadErr {
+				t.Errorf("get or load should return load error, but %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// the error is not cached, load again
+	c.GetInterfaceOrLoad(context.Background(), "a", loader)
+	if calls != 2 {
+		t.Fatalf("loader should be called twice, but %d", calls)
+	}
+
+	if _, _, exist := c.GetInterface("a"); exist {
+		t.Fatal("a should not exist")
+	}
+}
+
+func TestWithRefreshAhead(t *testing.T) {
+	c := NewWithOptions(WithRefreshAhead(150 * time.Millisecond))
+	defer c.ShutDown()
+
+	var calls int32
+	loader := func(ctx context.Context) ([]byte, time.Duration, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return []byte{byte('0' + n)}, 200 * time.Millisecond, nil
+	}
+
+	v, expire, _ := c.GetOrLoad(context.Background(), "a", loader)
+	if string(v) != "1" {
+		t.Fatalf("a should be 1, but %s", v)
+	}
+
+	// not in the window
+	c.Get("a")
+	time.Sleep(100 * time.Millisecond)
+
+	// in the window, return the current value and reload in background
+	v, _, _ = c.Get("a")
+	if string(v) != "1" {
+		t.Fatalf("a should be 1, but %s", v)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	v, newExpire, _ := c.Get("a")
+	if string(v) != "2" || newExpire <= expire {
+		t.Fatalf("a should be reloaded to 2, but %s", v)
+	}
+
+	if calls != 2 {
+		t.Fatalf("loader should be called twice, but %d", calls)
+	}
+}
@@ -0,0 +1,144 @@
+package gocache
+
+import (
+	"regexp"
+	"regexp/syntax"
+)
+
+// MatchPattern report whether the key matches the redis style glob pattern,
+// * match any sequence of characters, ? match any single character,
+// [abc] match one character in the brackets, [^abc] or [!abc] not in the brackets, [a-z] in the range,
+// \x match the character x literally
+func MatchPattern(pattern, key string) bool {
+	// the pattern after the last star and the key it try to match, a mismatch let the star match one more character,
+	// so the matching is O(len(pattern) * len(key)) instead of exponential
+	star := false
+	starPattern, starKey := "", ""
+	for len(pattern) > 0 || len(key) > 0 {
+		if len(pattern) > 0 && pattern[0] == '*' {
+			// skip the continuous stars
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+
+			if len(pattern) == 0 {
+				return true
+			}
+
+			star, starPattern, starKey = true, pattern, key
+			continue
+		}
+
+		if rest, ok := matchOne(pattern, key); ok {
+			pattern, key = rest, key[1:]
+			continue
+		}
+
+		if !star || len(starKey) == 0 {
+			return false
+		}
+
+		starKey = starKey[1:]
+		pattern, key = starPattern, starKey
+	}
+
+	return true
+}
+
+// matchOne match the first character of the key with the first element of the pattern which is not a star,
+// return the pattern after the element
+func matchOne(pattern, key string) (rest string, ok bool) {
+	if len(pattern) == 0 || len(key) == 0 {
+		return "", false
+	}
+
+	switch pattern[0] {
+	case '?':
+		return pattern[1:], true
+	case '[':
+		if matched, rest, ok := matchClass(pattern[1:], key[0]); ok {
+			return rest, matched
+		}
+		// no close bracket, match [ literally
+	case '\\':
+		if len(pattern) >= 2 {
+			pattern = pattern[1:]
+		}
+	}
+
+	return pattern[1:], pattern[0] == key[0]
+}
+
+// matchClass match c with the class after [, return the pattern after ], ok is false when no ]
+func matchClass(pattern string, c byte) (matched bool, rest string, ok bool) {
+	negate := false
+	if len(pattern) > 0 && (pattern[0] == '^' || pattern[0] == '!') {
+		negate = true
+		pattern = pattern[1:]
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == ']' && i > 0:
+			return matched != negate, pattern[i+1:], true
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if pattern[i] == c {
+				matched = true
+			}
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			lo, hi := pattern[i], pattern[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if lo <= c && c <= hi {
+				matched = true
+			}
+			i += 2
+		default:
+			if pattern[i] == c {
+				matched = true
+			}
+		}
+	}
+
+	return false, "", false
+}
+
+// patternPrefix return the literal prefix of the glob pattern, all the matched keys start with it
+func patternPrefix(pattern string) string {
+	prefix := make([]byte, 0, len(pattern))
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*', '?', '[':
+			return string(prefix)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+		}
+		prefix = append(prefix, pattern[i])
+	}
+
+	return string(prefix)
+}
+
+// regexpPrefix return the literal prefix of the regular expression anchored by ^, all the matched keys start with it
+func regexpPrefix(re *regexp.Regexp) string {
+	tree, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+
+	tree = tree.Simplify()
+	if tree.Op != syntax.OpConcat || len(tree.Sub) < 2 || tree.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+
+	literal := tree.Sub[1]
+	if literal.Op != syntax.OpLiteral || literal.Flags&syntax.FoldCase != 0 {
+		return ""
+	}
+
+	return string(literal.Rune)
+}
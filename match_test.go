@@ -0,0 +1,60 @@
+package gocache
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		match        bool
+	}{
+		{"*", "", true},
+		{"*", "abc", true},
+		{"tenant:42:*", "tenant:42:user:1", true},
+		{"tenant:42:*", "tenant:420:user:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h*llo", "heeeello", true},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"h[llo", "h[llo", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"a**b", "axxb", true},
+		{"*b?", "abbbc", true},
+		{"*[0-9]", "abc1", true},
+		{`*\*`, "ab*", true},
+		{`\`, `\`, true},
+		// exponential with the recursive matching
+		{strings.Repeat("a*", 30) + "b", strings.Repeat("a", 100), false},
+	}
+
+	for _, c := range cases {
+		if MatchPattern(c.pattern, c.key) != c.match {
+			t.Errorf("%s match %s should be %v", c.pattern, c.key, c.match)
+		}
+	}
+}
+
+func TestPatternPrefix(t *testing.T) {
+	for pattern, prefix := range map[string]string{"tenant:42:*": "tenant:42:", "a?c": "a", "[ab]": "", `a\*b*`: "a*b", `a\`: `a\`, "abc": "abc"} {
+		if got := patternPrefix(pattern); got != prefix {
+			t.Fatalf("prefix of %q should be %q, but %q", pattern, prefix, got)
+		}
+	}
+
+	for expr, prefix := range map[string]string{"^tenant:42:.*": "tenant:42:", "^user:[0-9]+$": "user:", "tenant:42:": "", "^a|^b": "", "(?i)^abc": "", "(?m)^abc": ""} {
+		if got := regexpPrefix(regexp.MustCompile(expr)); got != prefix {
+			t.Fatalf("prefix of %q should be %q, but %q", expr, prefix, got)
+		}
+	}
+}
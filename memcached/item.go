@@ -0,0 +1,231 @@
+package memcached
+
+import (
+	"encoding/gob"
+	"github.com/hunterhug/gocache"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+const (
+	// relativeExpireMax exptime not bigger than 30 days is relative to now, otherwise it is an absolute unix time
+	relativeExpireMax = 60 * 60 * 24 * 30
+	// neverExpire the expire time of the keys set with exptime 0, the janitor never see them
+	neverExpire = gocache.NeverExpire
+)
+
+// Item the value stored by SetInterface when the client flags is not zero,
+// the keys with zero flags are stored by Set so the other protocols can read them
+type Item struct {
+	Flags uint32
+	Value []byte
+}
+
+func init() {
+	// the snapshot and aof use gob by default
+	gob.Register(Item{})
+}
+
+type entry struct {
+	flags                        uint32
+	value                        []byte
+	expireUnixNanosecondDateTime int64
+	// cas the cas unique, set by load and store
+	cas uint64
+}
+
+// casVersion the cas unique of the value stored or read by the server,
+// a fingerprint of the value is kept to find out the writes by the other users of the cache
+type casVersion struct {
+	unique                       uint64
+	flags                        uint32
+	size                         int
+	sum                          uint64
+	expireUnixNanosecondDateTime int64
+}
+
+func newCasVersion(unique uint64, e entry) casVersion {
+	return casVersion{unique: unique, flags: e.flags, size: len(e.value), sum: fingerprint(e.value), expireUnixNanosecondDateTime: e.expireUnixNanosecondDateTime}
+}
+
+// fingerprint the fnv-1a hash of the value
+func fingerprint(value []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(value)
+	return h.Sum64()
+}
+
+// match report whether the entry is still the value of the version
+func (v casVersion) match(e entry) bool {
+	return v.flags == e.flags && v.expireUnixNanosecondDateTime == e.expireUnixNanosecondDateTime && v.size == len(e.value) && v.sum == fingerprint(e.value)
+}
+
+// ttl return the remaining seconds, -1 when never expire
+func (e entry) ttl() int64 {
+	if e.expireUnixNanosecondDateTime == neverExpire {
+		return -1
+	}
+
+	d := time.Duration(e.expireUnixNanosecondDateTime - time.Now().UnixNano())
+	return int64((d + time.Second/2) / time.Second)
+}
+
+// expireAt convert the memcached exptime to the unix nanosecond expire time,
+// 0 never expire, negative or absolute time in the past is expired already
+func expireAt(exptime int64) (expireUnixNanosecondDateTime int64, expired bool) {
+	now := time.Now().UnixNano()
+	switch {
+	case exptime == 0:
+		return neverExpire, false
+	case exptime < 0:
+		return 0, true
+	case exptime <= relativeExpireMax:
+		return now + exptime*int64(time.Second), false
+	case exptime > math.MaxInt64/int64(time.Second):
+		return neverExpire, false
+	}
+
+	expireUnixNanosecondDateTime = exptime * int64(time.Second)
+	return expireUnixNanosecondDateTime, expireUnixNanosecondDateTime <= now
+}
+
+// load get the key, the interface values not set by memcached are treated as not exist
+func (s *Server) load(key string) (e entry, exist bool) {
+	value, expireUnixNanosecondDateTime, exist := s.Cache.Get(key)
+	if !exist {
+		s.prune(key)
+		return
+	}
+
+	if value != nil {
+		e = entry{value: value, expireUnixNanosecondDateTime: expireUnixNanosecondDateTime}
+	} else {
+		// the key may be written between two gets, so read the bytes and the interface value again in one scan,
+		// Get above only count the hit and move the key to the front
+		if e, exist = s.peek(key); !exist {
+			s.prune(key)
+			return
+		}
+	}
+
+	e.cas = s.casOf(key, e)
+	return e, true
+}
+
+// peek read the key without counting a hit or moving it to the front
+func (s *Server) peek(key string) (entry, bool) {
+	entries := s.Cache.ScanRange(key, key+"\x00", 1)
+	if len(entries) == 0 {
+		return entry{}, false
+	}
+
+	return toEntry(entries[0])
+}
+
+func toEntry(item gocache.Entry) (entry, bool) {
+	e := entry{value: item.Value, expireUnixNanosecondDateTime: item.ExpireUnixNanosecondDateTime}
+	if item.Value != nil {
+		return e, true
+	}
+
+	switch v := item.Raw.(type) {
+	case nil:
+		return e, true
+	case Item:
+		e.flags, e.value = v.Flags, v.Value
+		return e, true
+	}
+
+	return entry{}, false
+}
+
+// casOf return the cas unique of the entry, a new one if the key is written by the other users of the cache
+func (s *Server) casOf(key string, e entry) uint64 {
+	s.casLocker.Lock()
+	defer s.casLocker.Unlock()
+	if v, ok := s.versions[key]; ok && v.match(e) {
+		return v.unique
+	}
+
+	return s.newCas(key, e)
+}
+
+// newCas increase the cas unique and record it as the version of the key, must hold the cas locker
+func (s *Server) newCas(key string, e entry) uint64 {
+	if s.versions == nil {
+		s.versions = make(map[string]casVersion)
+	}
+
+	// drop a few versions of the keys expired, deleted, evicted or written by the other users of the cache,
+	// the map iteration start at random, so the map is swept a little on every write
+	now := time.Now().UnixNano()
+	n := 0
+	for k, v := range s.versions {
+		if n++; n > 2 {
+			break
+		}
+
+		if k == key {
+			continue
+		}
+
+		if v.expireUnixNanosecondDateTime <= now {
+			delete(s.versions, k)
+		} else if current, exist := s.peek(k); !exist || !v.match(current) {
+			delete(s.versions, k)
+		}
+	}
+
+	s.casUnique++
+	s.versions[key] = newCasVersion(s.casUnique, e)
+	return s.casUnique
+}
+
+// prune drop the version of the key when it is missing in the cache,
+// the store hold the cas locker while setting, so a key set after the miss is seen by the peek
+func (s *Server) prune(key string) {
+	s.casLocker.Lock()
+	defer s.casLocker.Unlock()
+	if _, ok := s.versions[key]; !ok {
+		return
+	}
+
+	if _, exist := s.peek(key); !exist {
+		delete(s.versions, key)
+	}
+}
+
+// forget drop the versions of the key, all keys if key is empty
+func (s *Server) forget(key string) {
+	s.casLocker.Lock()
+	defer s.casLocker.Unlock()
+	if key == "" {
+		s.versions = nil
+		return
+	}
+
+	delete(s.versions, key)
+}
+
+// store set the key and return it with the new cas unique, delete it when the expire time is in the past,
+// must hold the write locker
+func (s *Server) store(key string, e entry) entry {
+	if e.expireUnixNanosecondDateTime <= time.Now().UnixNano() {
+		s.Cache.Delete(key)
+		s.forget(key)
+		return e
+	}
+
+	// a load between the set and the new version would record the value with another cas unique
+	s.casLocker.Lock()
+	defer s.casLocker.Unlock()
+	if e.flags == 0 {
+		s.Cache.SetByExpireUnixNanosecondDateTime(key, e.value, e.expireUnixNanosecondDateTime)
+	} else {
+		s.Cache.SetInterfaceByExpireUnixNanosecondDateTime(key, Item{Flags: e.flags, Value: e.value}, e.expireUnixNanosecondDateTime)
+	}
+
+	e.cas = s.newCas(key, e)
+	return e
+}
@@ -0,0 +1,274 @@
+package memcached
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+type metaFlag struct {
+	name  byte
+	token string
+}
+
+// parseMetaFlags parse the flags, return false if a flag is not in allowed
+func parseMetaFlags(args [][]byte, allowed string) ([]metaFlag, bool) {
+	flags := make([]metaFlag, 0, len(args))
+	for _, arg := range args {
+		if len(arg) == 0 || strings.IndexByte(allowed, arg[0]) < 0 {
+			return nil, false
+		}
+		flags = append(flags, metaFlag{name: arg[0], token: string(arg[1:])})
+	}
+	return flags, true
+}
+
+func hasMetaFlag(flags []metaFlag, name byte) (string, bool) {
+	for _, f := range flags {
+		if f.name == name {
+			return f.token, true
+		}
+	}
+	return "", false
+}
+
+// metaKey return the key, decode it if the b flag is set
+func metaKey(key []byte, flags []metaFlag) (string, bool) {
+	if _, ok := hasMetaFlag(flags, 'b'); !ok {
+		return string(key), validKey(key)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(string(key))
+	if err != nil || len(decoded) == 0 || len(decoded) > maxKeyLength {
+		return "", false
+	}
+	return string(decoded), true
+}
+
+// metaReturn build the returned flags in the order of the request,
+// the opaque and the key are returned for all the commands, the others only when the entry exist
+func metaReturn(key []byte, flags []metaFlag, e *entry) string {
+	var b strings.Builder
+	for _, f := range flags {
+		var ret string
+		switch f.name {
+		case 'O':
+			ret = "O" + f.token
+		case 'k':
+			ret = "k" + string(key)
+		case 'b':
+			if _, ok := hasMetaFlag(flags, 'k'); ok {
+				ret = "b"
+			}
+		}
+
+		if e != nil {
+			switch f.name {
+			case 'c':
+				ret = "c" + strconv.FormatUint(e.cas, 10)
+			case 'f':
+				ret = "f" + strconv.FormatUint(uint64(e.flags), 10)
+			case 's':
+				ret = "s" + strconv.Itoa(len(e.value))
+			case 't':
+				ret = "t" + strconv.FormatInt(e.ttl(), 10)
+			}
+		}
+
+		if ret != "" {
+			b.WriteString(" " + ret)
+		}
+	}
+	return b.String()
+}
+
+// metaGet mg <key> <flags>*
+//
+//	b key is base64 encoded, c return cas, f return client flags, k return key, O opaque,
+//	q not return EN on miss, s return size, t return remaining ttl, T<ttl> update ttl, v return value
+func (c *conn) metaGet(args [][]byte) {
+	if len(args) < 2 {
+		c.clientError("bad command line format")
+		return
+	}
+
+	flags, ok := parseMetaFlags(args[2:], "bcfkOqstTv")
+	if !ok {
+		c.clientError("invalid flag")
+		return
+	}
+
+	key, ok := metaKey(args[1], flags)
+	if !ok {
+		c.clientError("bad command line format")
+		return
+	}
+
+	c.s.cmdGet.Add(1)
+	var e entry
+	var exist bool
+	if ttl, ok := hasMetaFlag(flags, 'T'); ok {
+		exptime, err := strconv.ParseInt(ttl, 10, 64)
+		if err != nil {
+			c.clientError("bad token in command line format")
+			return
+		}
+		e, exist = c.s.touchBy(key, exptime)
+	} else {
+		e, exist = c.s.load(key)
+	}
+
+	if !exist {
+		c.s.getMisses.Add(1)
+		if _, quiet := hasMetaFlag(flags, 'q'); !quiet {
+			c.w.WriteString("EN\r\n")
+		}
+		return
+	}
+
+	c.s.getHits.Add(1)
+	ret := metaReturn(args[1], flags, &e)
+	if _, ok := hasMetaFlag(flags, 'v'); !ok {
+		c.w.WriteString("HD" + ret + "\r\n")
+		return
+	}
+
+	c.w.WriteString("VA " + strconv.Itoa(len(e.value)) + ret + "\r\n")
+	c.w.Write(e.value)
+	c.w.WriteString("\r\n")
+}
+
+// metaSet ms <key> <datalen> <flags>*
+//
+//	b key is base64 encoded, c return cas, C<cas> compare cas, F<flags> client flags, k return key,
+//	M<mode> E add, A append, P prepend, R replace, S set, O opaque, q not return HD, T<ttl> ttl
+func (c *conn) metaSet(args [][]byte) error {
+	if len(args) < 3 {
+		c.clientError("bad command line format")
+		return nil
+	}
+
+	length, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil || length < 0 {
+		// the data block can not be skipped
+		c.clientError("bad data chunk")
+		return errBadDataChunk
+	}
+
+	data, ok, err := c.readData(length)
+	if err != nil || !ok {
+		return err
+	}
+
+	flags, ok := parseMetaFlags(args[3:], "bcCFkMOqT")
+	if !ok {
+		c.clientError("invalid flag")
+		return nil
+	}
+
+	key, ok := metaKey(args[1], flags)
+	if !ok {
+		c.clientError("bad command line format")
+		return nil
+	}
+
+	e := entry{value: data, expireUnixNanosecondDateTime: neverExpire}
+	mode := modeSet
+	var cas uint64
+	for _, f := range flags {
+		var err error
+		switch f.name {
+		case 'C':
+			cas, err = strconv.ParseUint(f.token, 10, 64)
+		case 'F':
+			var n uint64
+			n, err = strconv.ParseUint(f.token, 10, 32)
+			e.flags = uint32(n)
+		case 'T':
+			var exptime int64
+			exptime, err = strconv.ParseInt(f.token, 10, 64)
+			e.expireUnixNanosecondDateTime, _ = expireAt(exptime)
+		case 'M':
+			if len(f.token) != 1 || strings.IndexByte("EAPRS", strings.ToUpper(f.token)[0]) < 0 {
+				c.clientError("invalid mode for ms")
+				return nil
+			}
+			mode = storeMode(strings.ToUpper(f.token)[0])
+		}
+
+		if err != nil {
+			c.clientError("bad token in command line format")
+			return nil
+		}
+	}
+
+	e, result := c.s.storeBy(key, mode, e, cas)
+	var ret string
+	if result == stored {
+		// return the cas of the new value
+		ret = metaReturn(args[1], flags, &e)
+	} else {
+		ret = metaReturn(args[1], flags, nil)
+	}
+
+	switch result {
+	case stored:
+		if _, quiet := hasMetaFlag(flags, 'q'); !quiet {
+			c.w.WriteString("HD" + ret + "\r\n")
+		}
+	case notStored:
+		c.w.WriteString("NS" + ret + "\r\n")
+	case exists:
+		c.w.WriteString("EX" + ret + "\r\n")
+	case notFound:
+		c.w.WriteString("NF" + ret + "\r\n")
+	}
+	return nil
+}
+
+// metaDelete md <key> <flags>*
+//
+//	b key is base64 encoded, C<cas> compare cas, k return key, O opaque, q not return HD and NF
+func (c *conn) metaDelete(args [][]byte) {
+	if len(args) < 2 {
+		c.clientError("bad command line format")
+		return
+	}
+
+	flags, ok := parseMetaFlags(args[2:], "bCkOq")
+	if !ok {
+		c.clientError("invalid flag")
+		return
+	}
+
+	key, ok := metaKey(args[1], flags)
+	if !ok {
+		c.clientError("bad command line format")
+		return
+	}
+
+	var cas uint64
+	if token, ok := hasMetaFlag(flags, 'C'); ok {
+		var err error
+		cas, err = strconv.ParseUint(token, 10, 64)
+		if err != nil {
+			c.clientError("bad token in command line format")
+			return
+		}
+	}
+
+	_, quiet := hasMetaFlag(flags, 'q')
+	ret := metaReturn(args[1], flags, nil)
+	switch c.s.deleteBy(key, cas) {
+	case stored:
+		if !quiet {
+			c.w.WriteString("HD" + ret + "\r\n")
+		}
+	case exists:
+		c.w.WriteString("EX" + ret + "\r\n")
+	case notFound:
+		if !quiet {
+			c.w.WriteString("NF" + ret + "\r\n")
+		}
+	}
+}
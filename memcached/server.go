@@ -0,0 +1,710 @@
+// Package memcached serve gocache.Cache over the memcached text protocol and meta protocol
+package memcached
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"github.com/hunterhug/gocache"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxKeyLength  = 250
+	maxLineLength = 64 << 10
+	// DefaultMaxItemSize the default max bytes of the value
+	DefaultMaxItemSize = 1 << 20
+	version            = "gocache"
+)
+
+var (
+	ErrServerClosed = errors.New("memcached: server closed")
+	errLineTooLong  = errors.New("memcached: line too long")
+	errBadDataChunk = errors.New("memcached: bad data chunk")
+)
+
+// Server serve the cache over the memcached protocol, the writes such as set, add, cas and incr
+// are atomic against the other connections of the server, but not against the other users of the cache.
+// The cas unique increase on every write of the server, the keys written by the other users get a new one when read
+type Server struct {
+	Cache gocache.Cache
+	// MaxItemSize the max bytes of the value, DefaultMaxItemSize if zero
+	MaxItemSize int
+
+	// writeLocker serialize the writes
+	writeLocker sync.Mutex
+	// casLocker guard the cas unique and the versions of the keys
+	casLocker sync.Mutex
+	casUnique uint64
+	versions  map[string]casVersion
+	locker    sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	startTime time.Time
+
+	totalConnections atomic.Uint64
+	cmdGet           atomic.Uint64
+	getHits          atomic.Uint64
+	getMisses        atomic.Uint64
+	cmdSet           atomic.Uint64
+	cmdTouch         atomic.Uint64
+	cmdFlush         atomic.Uint64
+}
+
+// ListenAndServe listen on the tcp addr and serve
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accept connections on the listener until it is closed, return ErrServerClosed after Close
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, nil) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l, nil)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		if !s.track(nil, conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		s.totalConnections.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// Close close the listeners and the connections
+func (s *Server) Close() error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return s.closed
+}
+
+func (s *Server) track(l net.Listener, conn net.Conn) bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.closed {
+		return false
+	}
+
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[net.Conn]struct{})
+		s.startTime = time.Now()
+	}
+
+	if l != nil {
+		s.listeners[l] = struct{}{}
+	}
+
+	if conn != nil {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener, conn net.Conn) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if l != nil {
+		delete(s.listeners, l)
+	}
+
+	if conn != nil {
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) currConnections() int {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return len(s.conns)
+}
+
+func (s *Server) maxItemSize() int {
+	if s.MaxItemSize > 0 {
+		return s.MaxItemSize
+	}
+	return DefaultMaxItemSize
+}
+
+type conn struct {
+	s *Server
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func (s *Server) serveConn(nc net.Conn) {
+	defer s.untrack(nil, nc)
+	defer nc.Close()
+
+	c := &conn{s: s, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			if err == errLineTooLong {
+				c.w.WriteString("CLIENT_ERROR line too long\r\n")
+				c.w.Flush()
+			}
+			return
+		}
+
+		quit := c.do(bytes.Fields(line))
+
+		// flush after the pipelined commands are all handled
+		if quit || c.r.Buffered() == 0 {
+			if err := c.w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// readLine read the command line without the \r\n
+func (c *conn) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := c.r.ReadSlice('\n')
+		if err == nil {
+			line = append(line, chunk...)
+			break
+		}
+
+		if err != bufio.ErrBufferFull {
+			return nil, err
+		}
+
+		line = append(line, chunk...)
+		if len(line) > maxLineLength {
+			return nil, errLineTooLong
+		}
+	}
+
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, nil
+}
+
+// readData read the data block followed by \r\n, ok is false when the data block is too large or not ended with \r\n,
+// the error is replied already
+func (c *conn) readData(length int64) (data []byte, ok bool, err error) {
+	if length > int64(c.s.maxItemSize()) {
+		if _, err := io.CopyN(io.Discard, c.r, length+2); err != nil {
+			return nil, false, err
+		}
+
+		c.w.WriteString("SERVER_ERROR object too large for cache\r\n")
+		return nil, false, nil
+	}
+
+	data = make([]byte, length+2)
+	if _, err := io.ReadFull(c.r, data); err != nil {
+		return nil, false, err
+	}
+
+	if data[length] != '\r' || data[length+1] != '\n' {
+		// swallow the rest of the line
+		if data[length+1] != '\n' {
+			if _, err := c.readLine(); err != nil {
+				return nil, false, err
+			}
+		}
+
+		c.w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return nil, false, nil
+	}
+
+	return data[:length], true, nil
+}
+
+func validKey(key []byte) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+
+	for _, b := range key {
+		if b <= ' ' || b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *conn) clientError(msg string) {
+	c.w.WriteString("CLIENT_ERROR " + msg + "\r\n")
+}
+
+// reply write the line unless noreply
+func (c *conn) reply(noreply bool, line string) {
+	if !noreply {
+		c.w.WriteString(line + "\r\n")
+	}
+}
+
+// noreply report whether the last arg is noreply, and return the args without it
+func noreply(args [][]byte) ([][]byte, bool) {
+	if len(args) > 0 && string(args[len(args)-1]) == "noreply" {
+		return args[:len(args)-1], true
+	}
+	return args, false
+}
+
+// do handle one command, return true when the connection should be closed
+func (c *conn) do(args [][]byte) (quit bool) {
+	if len(args) == 0 {
+		c.w.WriteString("ERROR\r\n")
+		return false
+	}
+
+	var err error
+	switch string(args[0]) {
+	case "get":
+		c.get(args, false)
+	case "gets":
+		c.get(args, true)
+	case "set", "add", "replace", "append", "prepend", "cas":
+		err = c.storage(args)
+	case "delete":
+		c.delete(args)
+	case "touch":
+		c.touch(args)
+	case "incr", "decr":
+		c.incr(args)
+	case "flush_all":
+		c.flushAll(args)
+	case "stats":
+		c.stats(args)
+	case "version":
+		c.w.WriteString("VERSION " + version + "\r\n")
+	case "verbosity":
+		_, nr := noreply(args)
+		c.reply(nr, "OK")
+	case "quit":
+		return true
+	case "mg":
+		c.metaGet(args)
+	case "ms":
+		err = c.metaSet(args)
+	case "md":
+		c.metaDelete(args)
+	case "mn":
+		c.w.WriteString("MN\r\n")
+	default:
+		c.w.WriteString("ERROR\r\n")
+	}
+
+	// the data block can not be read, close the connection
+	return err != nil
+}
+
+// get get <key>*
+func (c *conn) get(args [][]byte, withCas bool) {
+	if len(args) < 2 {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+
+	for _, key := range args[1:] {
+		if !validKey(key) {
+			c.clientError("bad command line format")
+			return
+		}
+	}
+
+	for _, key := range args[1:] {
+		c.s.cmdGet.Add(1)
+		e, exist := c.s.load(string(key))
+		if !exist {
+			c.s.getMisses.Add(1)
+			continue
+		}
+
+		c.s.getHits.Add(1)
+
+		c.w.WriteString("VALUE ")
+		c.w.Write(key)
+		c.w.WriteString(" " + strconv.FormatUint(uint64(e.flags), 10) + " " + strconv.Itoa(len(e.value)))
+		if withCas {
+			c.w.WriteString(" " + strconv.FormatUint(e.cas, 10))
+		}
+		c.w.WriteString("\r\n")
+		c.w.Write(e.value)
+		c.w.WriteString("\r\n")
+	}
+
+	c.w.WriteString("END\r\n")
+}
+
+type storeMode byte
+
+const (
+	modeSet     storeMode = 'S'
+	modeAdd     storeMode = 'E'
+	modeReplace storeMode = 'R'
+	modeAppend  storeMode = 'A'
+	modePrepend storeMode = 'P'
+)
+
+var storeModes = map[string]storeMode{
+	"set":     modeSet,
+	"add":     modeAdd,
+	"replace": modeReplace,
+	"append":  modeAppend,
+	"prepend": modePrepend,
+	"cas":     modeSet,
+}
+
+type storeResult int
+
+const (
+	stored storeResult = iota
+	notStored
+	exists
+	notFound
+)
+
+// storeBy write the entry by mode, compare the cas unique first if cas is not zero,
+// return the stored entry with the new cas unique
+func (s *Server) storeBy(key string, mode storeMode, e entry, cas uint64) (entry, storeResult) {
+	s.cmdSet.Add(1)
+	s.writeLocker.Lock()
+	defer s.writeLocker.Unlock()
+	if mode == modeSet && cas == 0 {
+		return s.store(key, e), stored
+	}
+
+	old, exist := s.load(key)
+	if cas != 0 {
+		if !exist {
+			return e, notFound
+		}
+
+		if old.cas != cas {
+			return e, exists
+		}
+	}
+
+	switch mode {
+	case modeAdd:
+		if exist {
+			return e, notStored
+		}
+	case modeReplace:
+		if !exist {
+			return e, notStored
+		}
+	case modeAppend, modePrepend:
+		if !exist {
+			return e, notStored
+		}
+
+		value := make([]byte, 0, len(old.value)+len(e.value))
+		if mode == modeAppend {
+			value = append(append(value, old.value...), e.value...)
+		} else {
+			value = append(append(value, e.value...), old.value...)
+		}
+
+		// append and prepend keep the flags and the expire time
+		old.value = value
+		e = old
+	}
+
+	return s.store(key, e), stored
+}
+
+// storage <command> <key> <flags> <exptime> <bytes> [<cas unique>] [noreply]
+func (c *conn) storage(args [][]byte) error {
+	args, nr := noreply(args)
+	command := string(args[0])
+	n := 5
+	if command == "cas" {
+		n = 6
+	}
+
+	if len(args) != n {
+		c.w.WriteString("ERROR\r\n")
+		return nil
+	}
+
+	length, err := strconv.ParseInt(string(args[4]), 10, 64)
+	if err != nil || length < 0 {
+		// the data block can not be skipped
+		c.clientError("bad data chunk")
+		return errBadDataChunk
+	}
+
+	data, ok, err := c.readData(length)
+	if err != nil || !ok {
+		return err
+	}
+
+	flags, err1 := strconv.ParseUint(string(args[2]), 10, 32)
+	exptime, err2 := strconv.ParseInt(string(args[3]), 10, 64)
+	if err1 != nil || err2 != nil || !validKey(args[1]) {
+		c.clientError("bad command line format")
+		return nil
+	}
+
+	var cas uint64
+	if command == "cas" {
+		cas, err = strconv.ParseUint(string(args[5]), 10, 64)
+		if err != nil || cas == 0 {
+			c.clientError("bad command line format")
+			return nil
+		}
+	}
+
+	expireUnixNanosecondDateTime, _ := expireAt(exptime)
+	e := entry{flags: uint32(flags), value: data, expireUnixNanosecondDateTime: expireUnixNanosecondDateTime}
+	switch _, result := c.s.storeBy(string(args[1]), storeModes[command], e, cas); result {
+	case stored:
+		c.reply(nr, "STORED")
+	case notStored:
+		c.reply(nr, "NOT_STORED")
+	case exists:
+		c.reply(nr, "EXISTS")
+	case notFound:
+		c.reply(nr, "NOT_FOUND")
+	}
+	return nil
+}
+
+// deleteBy delete the key, compare the cas unique first if cas is not zero
+func (s *Server) deleteBy(key string, cas uint64) storeResult {
+	s.writeLocker.Lock()
+	defer s.writeLocker.Unlock()
+	old, exist := s.load(key)
+	if !exist {
+		return notFound
+	}
+
+	if cas != 0 && old.cas != cas {
+		return exists
+	}
+
+	s.Cache.Delete(key)
+	s.forget(key)
+	return stored
+}
+
+// delete delete <key> [noreply]
+func (c *conn) delete(args [][]byte) {
+	args, nr := noreply(args)
+	if len(args) != 2 || !validKey(args[1]) {
+		c.clientError("bad command line format")
+		return
+	}
+
+	if c.s.deleteBy(string(args[1]), 0) == notFound {
+		c.reply(nr, "NOT_FOUND")
+		return
+	}
+
+	c.reply(nr, "DELETED")
+}
+
+// touchBy change the expire time of the key, return false if not exist
+func (s *Server) touchBy(key string, exptime int64) (entry, bool) {
+	s.cmdTouch.Add(1)
+	s.writeLocker.Lock()
+	defer s.writeLocker.Unlock()
+	e, exist := s.load(key)
+	if !exist {
+		return e, false
+	}
+
+	e.expireUnixNanosecondDateTime, _ = expireAt(exptime)
+	return s.store(key, e), true
+}
+
+// touch touch <key> <exptime> [noreply]
+func (c *conn) touch(args [][]byte) {
+	args, nr := noreply(args)
+	if len(args) != 3 || !validKey(args[1]) {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+
+	exptime, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		c.clientError("invalid exptime argument")
+		return
+	}
+
+	if _, exist := c.s.touchBy(string(args[1]), exptime); !exist {
+		c.reply(nr, "NOT_FOUND")
+		return
+	}
+
+	c.reply(nr, "TOUCHED")
+}
+
+// incr incr|decr <key> <value> [noreply], incr wrap around the 64 bit unsigned integer, decr stop at 0
+func (c *conn) incr(args [][]byte) {
+	args, nr := noreply(args)
+	if len(args) != 3 || !validKey(args[1]) {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+
+	delta, err := strconv.ParseUint(string(args[2]), 10, 64)
+	if err != nil {
+		c.clientError("invalid numeric delta argument")
+		return
+	}
+
+	c.s.writeLocker.Lock()
+	defer c.s.writeLocker.Unlock()
+	key := string(args[1])
+	e, exist := c.s.load(key)
+	if !exist {
+		c.reply(nr, "NOT_FOUND")
+		return
+	}
+
+	n, err := strconv.ParseUint(string(e.value), 10, 64)
+	if err != nil {
+		c.clientError("cannot increment or decrement non-numeric value")
+		return
+	}
+
+	if string(args[0]) == "incr" {
+		n += delta
+	} else if n > delta {
+		n -= delta
+	} else {
+		n = 0
+	}
+
+	e.value = strconv.AppendUint(nil, n, 10)
+	c.s.store(key, e)
+	c.reply(nr, string(e.value))
+}
+
+// flushAll flush_all [delay] [noreply]
+func (c *conn) flushAll(args [][]byte) {
+	args, nr := noreply(args)
+	if len(args) > 2 {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+
+	var delay int64
+	if len(args) == 2 {
+		var err error
+		delay, err = strconv.ParseInt(string(args[1]), 10, 64)
+		if err != nil || delay < 0 {
+			c.clientError("bad command line format")
+			return
+		}
+	}
+
+	c.s.cmdFlush.Add(1)
+	if delay == 0 {
+		c.s.flush()
+	} else {
+		time.AfterFunc(time.Duration(delay)*time.Second, c.s.flush)
+	}
+
+	c.reply(nr, "OK")
+}
+
+func (s *Server) flush() {
+	s.writeLocker.Lock()
+	defer s.writeLocker.Unlock()
+	for _, key := range s.Cache.KeyList() {
+		s.Cache.Delete(key)
+	}
+	s.forget("")
+}
+
+// stats stats [reset]
+func (c *conn) stats(args [][]byte) {
+	if len(args) == 2 && string(args[1]) == "reset" {
+		c.s.Cache.ResetStats()
+		c.w.WriteString("RESET\r\n")
+		return
+	}
+
+	if len(args) != 1 {
+		c.w.WriteString("ERROR\r\n")
+		return
+	}
+
+	s := c.s
+	stats := s.Cache.Stats()
+	now := time.Now()
+	for _, stat := range []struct {
+		name  string
+		value string
+	}{
+		{"pid", strconv.Itoa(os.Getpid())},
+		{"uptime", strconv.FormatInt(int64(now.Sub(s.startTime)/time.Second), 10)},
+		{"time", strconv.FormatInt(now.Unix(), 10)},
+		{"version", version},
+		{"curr_connections", strconv.Itoa(s.currConnections())},
+		{"total_connections", strconv.FormatUint(s.totalConnections.Load(), 10)},
+		{"cmd_get", strconv.FormatUint(s.cmdGet.Load(), 10)},
+		{"cmd_set", strconv.FormatUint(s.cmdSet.Load(), 10)},
+		{"cmd_flush", strconv.FormatUint(s.cmdFlush.Load(), 10)},
+		{"cmd_touch", strconv.FormatUint(s.cmdTouch.Load(), 10)},
+		{"get_hits", strconv.FormatUint(s.getHits.Load(), 10)},
+		{"get_misses", strconv.FormatUint(s.getMisses.Load(), 10)},
+		{"curr_items", strconv.Itoa(stats.Size)},
+		{"total_items", strconv.FormatUint(stats.Sets, 10)},
+		{"bytes", strconv.FormatInt(s.Cache.MemoryUsage(), 10)},
+		{"evictions", strconv.FormatUint(stats.CapacityEvictions, 10)},
+		{"expired", strconv.FormatUint(stats.LazyExpirations+stats.JanitorExpirations, 10)},
+		{"item_size_max", strconv.Itoa(s.maxItemSize())},
+	} {
+		c.w.WriteString("STAT " + stat.name + " " + stat.value + "\r\n")
+	}
+
+	c.w.WriteString("END\r\n")
+}
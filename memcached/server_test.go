@@ -0,0 +1,266 @@
+package memcached
+
+import (
+	"bufio"
+	"github.com/hunterhug/gocache"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *testClient) send(s string) {
+	if _, err := c.conn.Write([]byte(s)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) line() string {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+// expect send the command and check the reply lines
+func (c *testClient) expect(command string, lines ...string) {
+	c.t.Helper()
+	c.send(command)
+	for _, want := range lines {
+		if got := c.line(); got != want {
+			c.t.Fatalf("%q should reply %q, but %q", command, want, got)
+		}
+	}
+}
+
+func newTestServer(t *testing.T) (*testClient, gocache.Cache, func()) {
+	cache := gocache.New()
+	server := &Server{Cache: cache, MaxItemSize: 16}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go server.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}, cache, func() {
+		conn.Close()
+		server.Close()
+		cache.ShutDown()
+	}
+}
+
+func TestServer(t *testing.T) {
+	c, cache, done := newTestServer(t)
+	defer done()
+
+	c.expect("set user:1 0 0 4\r\na hi\r\n", "STORED")
+	c.expect("get user:1 none\r\n", "VALUE user:1 0 4", "a hi", "END")
+	if value, expire, _ := cache.Get("user:1"); string(value) != "a hi" || expire != neverExpire {
+		t.Fatalf("set with flags 0 should be visible by Get and never expire, but %q %d", value, expire)
+	}
+
+	c.expect("set user:2 7 100 4\r\nb hi\r\n", "STORED")
+	c.expect("get user:2\r\n", "VALUE user:2 7 4", "b hi", "END")
+	if _, expire, _ := cache.GetInterface("user:2"); expire < time.Now().Add(99*time.Second).UnixNano() || expire > time.Now().Add(100*time.Second).UnixNano() {
+		t.Fatalf("relative exptime should expire after 100s, but %d", expire)
+	}
+
+	absolute := time.Now().Add(time.Hour).Unix()
+	c.expect("set user:3 0 "+strconv.FormatInt(absolute, 10)+" 4 noreply\r\nc hi\r\nmn\r\n", "MN")
+	if _, expire, _ := cache.Get("user:3"); expire != absolute*int64(time.Second) {
+		t.Fatalf("absolute exptime should expire at %d, but %d", absolute*int64(time.Second), expire)
+	}
+
+	c.expect("set user:4 0 "+strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)+" 4\r\nd hi\r\n", "STORED")
+	c.expect("get user:4\r\n", "END")
+
+	c.expect("set big 0 0 17\r\n12345678901234567\r\n", "SERVER_ERROR object too large for cache")
+	c.expect("set bad 0 0 2\r\nabc\r\n", "CLIENT_ERROR bad data chunk")
+	c.expect("set bad 0 0\r\n", "ERROR")
+
+	c.expect("add user:1 0 0 1\r\nx\r\n", "NOT_STORED")
+	c.expect("add user:5 0 0 1\r\nx\r\n", "STORED")
+	c.expect("replace none 0 0 1\r\nx\r\n", "NOT_STORED")
+	c.expect("replace user:5 0 0 1\r\ny\r\n", "STORED")
+	c.expect("append user:5 0 0 1\r\nz\r\n", "STORED")
+	c.expect("prepend user:5 0 0 1\r\nx\r\n", "STORED")
+	c.expect("get user:5\r\n", "VALUE user:5 0 3", "xyz", "END")
+
+	c.send("gets user:5\r\n")
+	header := strings.Fields(c.line())
+	c.line()
+	c.line()
+	if len(header) != 5 {
+		t.Fatalf("gets should return cas, but %v", header)
+	}
+	c.expect("cas user:5 0 0 1 1\r\nw\r\n", "EXISTS")
+	c.expect("cas user:5 0 0 1 "+header[4]+"\r\nw\r\n", "STORED")
+	c.expect("cas none 0 0 1 1\r\nw\r\n", "NOT_FOUND")
+
+	c.expect("touch user:5 10\r\n", "TOUCHED")
+	c.expect("touch none 10\r\n", "NOT_FOUND")
+	if _, expire, _ := cache.Get("user:5"); expire > time.Now().Add(10*time.Second).UnixNano() {
+		t.Fatalf("touch should change the expire time, but %d", expire)
+	}
+
+	c.expect("set n 3 0 2\r\n10\r\n", "STORED")
+	c.expect("incr n 5\r\n", "15")
+	c.expect("decr n 100\r\n", "0")
+	c.expect("incr n 18446744073709551615\r\n", "18446744073709551615")
+	c.expect("incr n 1\r\n", "0")
+	c.expect("get n\r\n", "VALUE n 3 1", "0", "END")
+	c.expect("incr user:1 1\r\n", "CLIENT_ERROR cannot increment or decrement non-numeric value")
+	c.expect("incr none 1\r\n", "NOT_FOUND")
+
+	c.expect("delete user:1\r\n", "DELETED")
+	c.expect("delete user:1\r\n", "NOT_FOUND")
+
+	c.send("stats\r\n")
+	stats := map[string]string{}
+	for line := c.line(); line != "END"; line = c.line() {
+		fields := strings.Fields(line)
+		stats[fields[1]] = fields[2]
+	}
+	if stats["curr_items"] != "4" || stats["get_hits"] != "5" || stats["get_misses"] != "2" || stats["version"] != version {
+		t.Fatalf("stats wrong: %v", stats)
+	}
+
+	c.expect("flush_all\r\n", "OK")
+	if cache.Size() != 0 {
+		t.Fatalf("flush_all should delete all the keys, but %d", cache.Size())
+	}
+
+	c.expect("nope\r\n", "ERROR")
+	c.expect("version\r\n", "VERSION "+version)
+}
+
+func TestServerMeta(t *testing.T) {
+	c, _, done := newTestServer(t)
+	defer done()
+
+	c.expect("ms user:1 4 F5 T100 k Oab\r\na hi\r\n", "HD kuser:1 Oab")
+	c.expect("mg user:1 v f t k s\r\n", "VA 4 f5 t100 kuser:1 s4", "a hi")
+	c.expect("mg user:1\r\n", "HD")
+	c.expect("mg none v\r\n", "EN")
+
+	// the quiet miss is not returned, mn mark the end
+	c.expect("mg none v q Oxy\r\nmn\r\n", "MN")
+
+	c.expect("ms user:1 1 ME\r\nx\r\n", "NS")
+	c.expect("ms user:2 1 MR\r\nx\r\n", "NS")
+	c.expect("ms user:1 1 MA q\r\nx\r\nmn\r\n", "MN")
+	c.expect("mg user:1 v\r\n", "VA 5", "a hix")
+
+	c.send("mg user:1 c\r\n")
+	cas := strings.TrimPrefix(c.line(), "HD c")
+	c.expect("ms user:1 1 C1\r\ny\r\n", "EX")
+	c.send("ms user:1 1 C" + cas + " c\r\ny\r\n")
+	if line := c.line(); !strings.HasPrefix(line, "HD c") || line == "HD c"+cas {
+		t.Fatalf("ms with cas should return the new cas, but %q", line)
+	}
+
+	c.expect("mg user:1 T1 t v\r\n", "VA 1 t1", "y")
+	c.expect("ms dXNlcjoz 1 b k\r\nz\r\n", "HD b kdXNlcjoz")
+	c.expect("mg user:3 v\r\n", "VA 1", "z")
+
+	c.expect("md user:1 C1\r\n", "EX")
+	c.expect("md user:1 q\r\nmn\r\n", "MN")
+	c.expect("md user:1\r\n", "NF")
+	c.expect("mg user:1 x\r\n", "CLIENT_ERROR invalid flag")
+}
+
+func TestServerCas(t *testing.T) {
+	c, cache, done := newTestServer(t)
+	defer done()
+
+	gets := func(key string) string {
+		c.send("gets " + key + "\r\n")
+		header := strings.Fields(c.line())
+		for line := c.line(); line != "END"; line = c.line() {
+		}
+		if len(header) != 5 {
+			t.Fatalf("gets should return cas, but %v", header)
+		}
+		return header[4]
+	}
+
+	// the same value written again get a new cas unique
+	c.expect("set user:1 0 0 1\r\na\r\n", "STORED")
+	cas := gets("user:1")
+	if gets("user:1") != cas {
+		t.Fatalf("cas should not change when read")
+	}
+	c.expect("set user:1 0 0 1\r\nb\r\n", "STORED")
+	c.expect("set user:1 0 0 1\r\na\r\n", "STORED")
+	c.expect("cas user:1 0 0 1 "+cas+"\r\nc\r\n", "EXISTS")
+
+	// the keys written by the other users of the cache
+	cache.Set("user:2", []byte("a"), time.Minute)
+	cache.SetInterface("user:3", Item{Flags: 3, Value: []byte("b")}, time.Minute)
+	cas = gets("user:2")
+	cache.Set("user:2", []byte("b"), time.Minute)
+	c.expect("cas user:2 0 0 1 "+cas+"\r\nc\r\n", "EXISTS")
+	c.expect("cas user:2 0 0 1 "+gets("user:2")+"\r\nc\r\n", "STORED")
+	c.expect("cas user:3 3 0 1 "+gets("user:3")+"\r\nc\r\n", "STORED")
+	c.expect("get user:2 user:3\r\n", "VALUE user:2 0 1", "c", "VALUE user:3 3 1", "c", "END")
+}
+
+func TestServerVersions(t *testing.T) {
+	cache := gocache.New()
+	defer cache.ShutDown()
+	s := &Server{Cache: cache}
+
+	for i := 0; i < 10; i++ {
+		s.store("user:"+strconv.Itoa(i), entry{value: []byte("a"), expireUnixNanosecondDateTime: neverExpire})
+	}
+
+	// the keys deleted by the other users of the cache are dropped by a load miss
+	cache.Delete("user:0")
+	if _, exist := s.load("user:0"); exist || len(s.versions) != 9 {
+		t.Fatalf("load miss should drop the version, but %d versions", len(s.versions))
+	}
+
+	// or by the writes of the other keys
+	for i := 1; i < 10; i++ {
+		cache.Delete("user:" + strconv.Itoa(i))
+	}
+	for i := 0; i < 9; i++ {
+		s.store("order:1", entry{value: []byte("a"), expireUnixNanosecondDateTime: neverExpire})
+	}
+	if len(s.versions) != 1 {
+		t.Fatalf("the versions of the deleted keys should be dropped, but %d versions", len(s.versions))
+	}
+}
+
+func TestExpireAt(t *testing.T) {
+	now := time.Now()
+	if e, expired := expireAt(0); e != neverExpire || expired {
+		t.Fatalf("0 should never expire")
+	}
+
+	if _, expired := expireAt(-1); !expired {
+		t.Fatalf("negative should be expired")
+	}
+
+	if e, _ := expireAt(relativeExpireMax); e < now.Add(relativeExpireMax*time.Second).UnixNano() {
+		t.Fatalf("30 days should be relative")
+	}
+
+	if e, _ := expireAt(relativeExpireMax + 1); e != (relativeExpireMax+1)*int64(time.Second) {
+		t.Fatalf("more than 30 days should be absolute")
+	}
+}
@@ -0,0 +1,176 @@
+// Package metrics render the gocache counters in the Prometheus text exposition format
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/hunterhug/gocache"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler http.Handler serve the metrics of the registered caches, each cache is labeled by cache="name"
+type Handler struct {
+	locker sync.RWMutex
+	caches map[string]gocache.Cache
+}
+
+// NewHandler new a handler without any cache
+func NewHandler() *Handler {
+	return &Handler{caches: make(map[string]gocache.Cache)}
+}
+
+// Register add the cache with the name, the cache with the same name will be replaced
+func (h *Handler) Register(name string, c gocache.Cache) {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+	h.caches[name] = c
+}
+
+// Unregister remove the cache with the name
+func (h *Handler) Unregister(name string) {
+	h.locker.Lock()
+	defer h.locker.Unlock()
+	delete(h.caches, name)
+}
+
+type cacheMetrics struct {
+	name        string
+	stats       gocache.Stats
+	memoryUsage int64
+	// seconds since the oldest key expired
+	oldestExpiryAge float64
+	hasOldest       bool
+}
+
+func (h *Handler) collect() []cacheMetrics {
+	h.locker.RLock()
+	names := make([]string, 0, len(h.caches))
+	for name := range h.caches {
+		names = append(names, name)
+	}
+	caches := make(map[string]gocache.Cache, len(h.caches))
+	for name, c := range h.caches {
+		caches[name] = c
+	}
+	h.locker.RUnlock()
+
+	sort.Strings(names)
+	metrics := make([]cacheMetrics, 0, len(names))
+	for _, name := range names {
+		c := caches[name]
+		m := cacheMetrics{
+			name:        name,
+			stats:       c.Stats(),
+			memoryUsage: c.MemoryUsage(),
+		}
+
+		if _, expireUnixNanosecondDateTime, exist := c.GetOldestKey(); exist {
+			m.hasOldest = true
+			m.oldestExpiryAge = float64(time.Now().UnixNano()-expireUnixNanosecondDateTime) / float64(time.Second)
+		}
+
+		metrics = append(metrics, m)
+	}
+
+	return metrics
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	metrics := h.collect()
+
+	w.Header().Set("Content-Type", contentType)
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(s *gocache.Stats) uint64
+	}{
+		{"gocache_hits_total", "Number of Get or GetInterface found the key.", func(s *gocache.Stats) uint64 { return s.Hits }},
+		{"gocache_misses_total", "Number of Get or GetInterface not found the key.", func(s *gocache.Stats) uint64 { return s.Misses }},
+		{"gocache_sets_total", "Number of keys written.", func(s *gocache.Stats) uint64 { return s.Sets }},
+		{"gocache_deletes_total", "Number of keys removed by Delete.", func(s *gocache.Stats) uint64 { return s.Deletes }},
+		{"gocache_capacity_evictions_total", "Number of keys evicted because of the max entries or the max memory.", func(s *gocache.Stats) uint64 { return s.CapacityEvictions }},
+	}
+
+	for _, counter := range counters {
+		writeHeader(bw, counter.name, counter.help, "counter")
+		for _, m := range metrics {
+			writeSample(bw, counter.name, labels("cache", m.name), strconv.FormatUint(counter.value(&m.stats), 10))
+		}
+	}
+
+	writeHeader(bw, "gocache_expirations_total", "Number of expired keys removed, by lazy get or by the background janitor.", "counter")
+	for _, m := range metrics {
+		writeSample(bw, "gocache_expirations_total", labels("cache", m.name, "source", "lazy"), strconv.FormatUint(m.stats.LazyExpirations, 10))
+		writeSample(bw, "gocache_expirations_total", labels("cache", m.name, "source", "janitor"), strconv.FormatUint(m.stats.JanitorExpirations, 10))
+	}
+
+	writeHeader(bw, "gocache_size", "Current number of keys.", "gauge")
+	for _, m := range metrics {
+		writeSample(bw, "gocache_size", labels("cache", m.name), strconv.Itoa(m.stats.Size))
+	}
+
+	writeHeader(bw, "gocache_memory_usage_bytes", "Approximate memory cost of all keys.", "gauge")
+	for _, m := range metrics {
+		writeSample(bw, "gocache_memory_usage_bytes", labels("cache", m.name), strconv.FormatInt(m.memoryUsage, 10))
+	}
+
+	writeHeader(bw, "gocache_oldest_expiry_age_seconds", "Seconds since the oldest key expired, negative when it is not expired yet.", "gauge")
+	for _, m := range metrics {
+		if m.hasOldest {
+			writeSample(bw, "gocache_oldest_expiry_age_seconds", labels("cache", m.name), formatFloat(m.oldestExpiryAge))
+		}
+	}
+
+	name := "gocache_janitor_run_duration_seconds"
+	writeHeader(bw, name, "Duration of the background janitor runs.", "histogram")
+	for _, m := range metrics {
+		var cumulative uint64
+		for i, bound := range gocache.JanitorRunDurationBounds {
+			cumulative += m.stats.JanitorRunDurationBuckets[i]
+			writeSample(bw, name+"_bucket", labels("cache", m.name, "le", formatFloat(bound.Seconds())), strconv.FormatUint(cumulative, 10))
+		}
+		writeSample(bw, name+"_bucket", labels("cache", m.name, "le", "+Inf"), strconv.FormatUint(m.stats.JanitorRuns, 10))
+		writeSample(bw, name+"_sum", labels("cache", m.name), formatFloat(m.stats.JanitorRunDuration.Seconds()))
+		writeSample(bw, name+"_count", labels("cache", m.name), strconv.FormatUint(m.stats.JanitorRuns, 10))
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(w *bufio.Writer, name, labels, value string) {
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, value)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels format the label pairs name1, value1, name2, value2...
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelValueReplacer.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
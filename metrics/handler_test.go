@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/hunterhug/gocache"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	a := gocache.New()
+	defer a.ShutDown()
+	b := gocache.NewSharded(4)
+	defer b.ShutDown()
+
+	a.Set("a", []byte("a"), time.Minute)
+	a.Get("a")
+	a.Get("x")
+	b.Set("b", []byte("b"), time.Minute)
+	// the key never expire has no expiry age
+	c := gocache.New()
+	defer c.ShutDown()
+	c.Set("c", []byte("c"), gocache.NoExpiration)
+
+	h := NewHandler()
+	h.Register("a", a)
+	h.Register(`b"1`, b)
+	h.Register("c", c)
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	for _, line := range []string{
+		"# TYPE gocache_hits_total counter",
+		`gocache_hits_total{cache="a"} 1`,
+		`gocache_misses_total{cache="a"} 1`,
+		`gocache_sets_total{cache="b\"1"} 1`,
+		`gocache_size{cache="a"} 1`,
+		`gocache_expirations_total{cache="a",source="lazy"} 0`,
+		"# TYPE gocache_janitor_run_duration_seconds histogram",
+		`gocache_janitor_run_duration_seconds_bucket{cache="a",le="+Inf"} 0`,
+		`gocache_oldest_expiry_age_seconds{cache="a"} -`,
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("metrics should contain %s, but:\n%s", line, body)
+		}
+	}
+
+	if strings.Contains(string(body), `gocache_oldest_expiry_age_seconds{cache="c"}`) {
+		t.Fatalf("the key never expire should not be the oldest, but:\n%s", body)
+	}
+
+	if resp.Header.Get("Content-Type") != contentType {
+		t.Fatalf("content type should be %s, but %s", contentType, resp.Header.Get("Content-Type"))
+	}
+}
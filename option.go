@@ -0,0 +1,157 @@
+package gocache
+
+import "time"
+
+const (
+	defaultCleanInterval    = time.Second
+	defaultCleanMaxRemovals = 30
+	defaultHeapInitCap      = 100
+)
+
+// Option config the cache created by NewWithOptions
+type Option func(*options)
+
+type options struct {
+	// max number of keys, 0 means no limit
+	maxEntries int
+	// max memory cost of all keys, 0 means no limit
+	maxMemory int64
+	// cost of the value set by SetInterface
+	costFunc CostFunc
+	// how often the janitor clean the expired keys
+	cleanInterval time.Duration
+	// max number of expired keys the janitor remove per cycle
+	cleanMaxRemovals int
+	// initial capacity of the min heap
+	heapInitCap int
+	// start the background janitor or not
+	janitor bool
+	// reload the key set by GetOrLoad in background when it is going to expire within the window
+	refreshAheadWindow time.Duration
+	// encode the value set by SetInterface when save snapshot or append to aof
+	codec Codec
+	// min size of the append only file to rewrite
+	aofRewriteMinSize int64
+	// called when the append only file can not be written
+	aofErrorFunc func(err error)
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		cleanInterval:     defaultCleanInterval,
+		cleanMaxRemovals:  defaultCleanMaxRemovals,
+		heapInitCap:       defaultHeapInitCap,
+		janitor:           true,
+		codec:             GobCodec{},
+		aofRewriteMinSize: defaultAOFRewriteMinSize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// CostFunc return the approximate memory cost of the value set by SetInterface
+type CostFunc func(key string, value interface{}) int64
+
+// WithMaxEntries limit the number of keys in cache,
+// when the limit is hit, the least recently used key will be evicted
+func WithMaxEntries(maxEntries int) Option {
+	return func(o *options) {
+		if maxEntries > 0 {
+			o.maxEntries = maxEntries
+		}
+	}
+}
+
+// WithMaxMemory limit the approximate memory cost of all keys in cache,
+// Set charge len(key)+len(value), SetInterface charge the cost returned by CostFunc,
+// when the budget is exceeded, the soonest expiring keys will be evicted until it fits
+func WithMaxMemory(maxMemory int64) Option {
+	return func(o *options) {
+		if maxMemory > 0 {
+			o.maxMemory = maxMemory
+		}
+	}
+}
+
+// WithCostFunc set the cost func of the value set by SetInterface,
+// without it the value set by SetInterface charge len(key)
+func WithCostFunc(costFunc CostFunc) Option {
+	return func(o *options) {
+		o.costFunc = costFunc
+	}
+}
+
+// WithCleanInterval set how often the background janitor clean the expired keys, default is 1 second
+func WithCleanInterval(interval time.Duration) Option {
+	return func(o *options) {
+		if interval > 0 {
+			o.cleanInterval = interval
+		}
+	}
+}
+
+// WithCleanMaxRemovals set the max number of expired keys the janitor remove per cycle, default is 30
+func WithCleanMaxRemovals(maxRemovals int) Option {
+	return func(o *options) {
+		if maxRemovals > 0 {
+			o.cleanMaxRemovals = maxRemovals
+		}
+	}
+}
+
+// WithHeapInitCap set the initial capacity of the min heap, default is 100
+func WithHeapInitCap(initCap int) Option {
+	return func(o *options) {
+		if initCap > 0 {
+			o.heapInitCap = initCap
+		}
+	}
+}
+
+// WithJanitor start the background janitor or not, default is true,
+// without janitor the expired keys only be removed when get them
+func WithJanitor(janitor bool) Option {
+	return func(o *options) {
+		o.janitor = janitor
+	}
+}
+
+// WithRefreshAhead reload the key set by GetOrLoad or GetInterfaceOrLoad in background
+// when get it within the window before it expire, the current value is still returned until the reload finish
+func WithRefreshAhead(window time.Duration) Option {
+	return func(o *options) {
+		if window > 0 {
+			o.refreshAheadWindow = window
+		}
+	}
+}
+
+// WithCodec set the codec to encode the value set by SetInterface when save snapshot or append to aof, default is GobCodec
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		if codec != nil {
+			o.codec = codec
+		}
+	}
+}
+
+// WithAOFRewriteMinSize set the min size of the append only file to rewrite, default is 64MB
+func WithAOFRewriteMinSize(minSize int64) Option {
+	return func(o *options) {
+		if minSize > 0 {
+			o.aofRewriteMinSize = minSize
+		}
+	}
+}
+
+// WithAOFErrorFunc set the func called when a write can not be appended to the append only file
+// or the background rewrite fail, it is called after the cache is unlocked
+func WithAOFErrorFunc(f func(err error)) Option {
+	return func(o *options) {
+		o.aofErrorFunc = f
+	}
+}
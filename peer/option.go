@@ -0,0 +1,103 @@
+package peer
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultBasePath    = "/_gocache/"
+	defaultReplicas    = 50
+	defaultHTTPTimeout = 3 * time.Second
+	defaultMaxBodySize = 64 << 20
+)
+
+// Getter load the value of the key on the owner node when it is missing in cache
+type Getter func(ctx context.Context, key string) (value []byte, expireTime time.Duration, err error)
+
+// Option config the pool created by NewPool
+type Option func(*options)
+
+type options struct {
+	// url path prefix of the peer requests
+	basePath string
+	// number of virtual nodes of each peer on the hash ring
+	replicas int
+	// mirror the value fetched from the owner locally for at most mirrorTTL, 0 means not mirror
+	mirrorTTL  time.Duration
+	getter     Getter
+	httpClient *http.Client
+
+	// the max size of the value put by the peers
+	maxBodySize int64
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		basePath:    defaultBasePath,
+		replicas:    defaultReplicas,
+		httpClient:  &http.Client{Timeout: defaultHTTPTimeout},
+		maxBodySize: defaultMaxBodySize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// WithBasePath set the url path prefix of the peer requests, default is /_gocache/
+func WithBasePath(basePath string) Option {
+	return func(o *options) {
+		if basePath != "" {
+			o.basePath = basePath
+		}
+	}
+}
+
+// WithReplicas set the number of virtual nodes of each peer on the hash ring, default is 50
+func WithReplicas(replicas int) Option {
+	return func(o *options) {
+		if replicas > 0 {
+			o.replicas = replicas
+		}
+	}
+}
+
+// WithMirrorTTL mirror the value fetched from the owner in the local cache,
+// it expire after ttl or when it expire in the owner, whichever is earlier
+func WithMirrorTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		if ttl > 0 {
+			o.mirrorTTL = ttl
+		}
+	}
+}
+
+// WithGetter set the getter to load the missing keys on the owner node,
+// without getter the missing keys are not found
+func WithGetter(getter Getter) Option {
+	return func(o *options) {
+		o.getter = getter
+	}
+}
+
+// WithHTTPClient set the client to request the peers, default timeout is 3 seconds
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		if client != nil {
+			o.httpClient = client
+		}
+	}
+}
+
+// WithMaxBodySize set the max size of the value put by the peers, the bigger values are rejected, default is 64MB
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.maxBodySize = size
+		}
+	}
+}
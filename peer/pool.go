@@ -0,0 +1,285 @@
+// Package peer distribute the keys across the nodes by consistent hashing, each key is owned by one node,
+// the other nodes fetch it from the owner over HTTP, like groupcache
+package peer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hunterhug/gocache"
+	"github.com/hunterhug/gocache/algorithm"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderExpire the expire unix nanosecond of the value in the peer requests
+const HeaderExpire = "X-Gocache-Expire-Unix-Nanosecond"
+
+var errNotFound = errors.New("peer: key not found")
+
+// Pool one node of the cluster, it serve the keys owned by itself to the peers and fetch the others from their owners
+type Pool struct {
+	self  string
+	cache gocache.Cache
+	opts  options
+	ring  *algorithm.HashRing
+}
+
+// NewPool new the node, self is the base url of this node such as http://10.0.0.1:8080,
+// the keys owned by this node and the mirrored keys are kept in cache
+func NewPool(self string, cache gocache.Cache, opts ...Option) *Pool {
+	p := new(Pool)
+	p.self = strings.TrimSuffix(self, "/")
+	p.cache = cache
+	p.opts = newOptions(opts...)
+	p.ring = algorithm.NewHashRing(p.opts.replicas, nil)
+	p.ring.Add(p.self)
+	return p
+}
+
+// AddPeers add the base urls of the peers, the keys are rebalanced to them
+func (p *Pool) AddPeers(peers ...string) {
+	p.ring.Add(trimPeers(peers)...)
+}
+
+// RemovePeers remove the peers, only their keys move to the other peers
+func (p *Pool) RemovePeers(peers ...string) {
+	p.ring.Remove(trimPeers(peers)...)
+}
+
+func trimPeers(peers []string) []string {
+	trimmed := make([]string, len(peers))
+	for i, peer := range peers {
+		trimmed[i] = strings.TrimSuffix(peer, "/")
+	}
+	return trimmed
+}
+
+// Peers return the base urls of all the nodes include self
+func (p *Pool) Peers() []string {
+	return p.ring.Nodes()
+}
+
+// Owner return the base url of the node owning the key
+func (p *Pool) Owner(key string) string {
+	return p.ring.Get(key)
+}
+
+func (p *Pool) isLocal(owner string) bool {
+	return owner == "" || owner == p.self
+}
+
+// Get get the key from the local cache first, then from the owner,
+// the owner load it by the getter when missing, if the owner can not be reached the key is loaded locally
+func (p *Pool) Get(ctx context.Context, key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	value, expireUnixNanosecondDateTime, exist = p.cache.Get(key)
+	if exist {
+		return
+	}
+
+	owner := p.ring.Get(key)
+	if p.isLocal(owner) {
+		return p.load(ctx, key)
+	}
+
+	if p.opts.mirrorTTL > 0 {
+		// the concurrent fetches of one key are coalesced by GetOrLoad
+		value, expireUnixNanosecondDateTime, err = p.cache.GetOrLoad(ctx, key, func(ctx context.Context) ([]byte, time.Duration, error) {
+			value, expireUnixNanosecondDateTime, exist, err := p.fetch(ctx, owner, key)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if !exist {
+				return nil, 0, errNotFound
+			}
+
+			ttl := time.Duration(expireUnixNanosecondDateTime - time.Now().UnixNano())
+			if ttl > p.opts.mirrorTTL {
+				ttl = p.opts.mirrorTTL
+			}
+			return value, ttl, nil
+		})
+		if err == nil {
+			return value, expireUnixNanosecondDateTime, true, nil
+		}
+
+		if err == errNotFound {
+			return nil, 0, false, nil
+		}
+	} else {
+		value, expireUnixNanosecondDateTime, exist, err = p.fetch(ctx, owner, key)
+		if err == nil {
+			return
+		}
+	}
+
+	if ctx.Err() != nil || p.opts.getter == nil {
+		return nil, 0, false, err
+	}
+
+	return p.load(ctx, key)
+}
+
+// load load the key locally by the getter
+func (p *Pool) load(ctx context.Context, key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	if p.opts.getter == nil {
+		return nil, 0, false, nil
+	}
+
+	value, expireUnixNanosecondDateTime, err = p.cache.GetOrLoad(ctx, key, func(ctx context.Context) ([]byte, time.Duration, error) {
+		return p.opts.getter(ctx, key)
+	})
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return value, expireUnixNanosecondDateTime, true, nil
+}
+
+// Set set the key in the owner, the local mirror is dropped
+func (p *Pool) Set(ctx context.Context, key string, value []byte, expireTime time.Duration) error {
+	expireUnixNanosecondDateTime := gocache.ExpireAfter(expireTime)
+	owner := p.ring.Get(key)
+	if p.isLocal(owner) {
+		p.cache.SetByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+		return nil
+	}
+
+	p.cache.Delete(key)
+	_, err := p.request(ctx, http.MethodPut, owner, key, value, expireUnixNanosecondDateTime)
+	return err
+}
+
+// Delete delete the key in the owner and the local mirror
+func (p *Pool) Delete(ctx context.Context, key string) error {
+	p.cache.Delete(key)
+	owner := p.ring.Get(key)
+	if p.isLocal(owner) {
+		return nil
+	}
+
+	_, err := p.request(ctx, http.MethodDelete, owner, key, nil, 0)
+	return err
+}
+
+func (p *Pool) keyURL(peer, key string) string {
+	return peer + p.opts.basePath + url.PathEscape(key)
+}
+
+// request send the request to the peer, the response body is closed if it is not ok
+func (p *Pool) request(ctx context.Context, method, peer, key string, body []byte, expireUnixNanosecondDateTime int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.keyURL(peer, key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if expireUnixNanosecondDateTime != 0 {
+		req.Header.Set(HeaderExpire, strconv.FormatInt(expireUnixNanosecondDateTime, 10))
+	}
+
+	resp, err := p.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		return resp, nil
+	}
+
+	return nil, fmt.Errorf("peer: %s %s return %s", method, peer, resp.Status)
+}
+
+// fetch get the key from the peer
+func (p *Pool) fetch(ctx context.Context, peer, key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool, err error) {
+	resp, err := p.request(ctx, http.MethodGet, peer, key, nil, 0)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, false, nil
+	}
+	defer resp.Body.Close()
+
+	expireUnixNanosecondDateTime, err = strconv.ParseInt(resp.Header.Get(HeaderExpire), 10, 64)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("peer: %s return invalid expire: %w", peer, err)
+	}
+
+	value, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	return value, expireUnixNanosecondDateTime, true, nil
+}
+
+// ServeHTTP serve the peer requests under the base path,
+// the keys are served from the local cache even if the ring changed, so the requests never loop
+func (p *Pool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	escaped := strings.TrimPrefix(r.URL.EscapedPath(), p.opts.basePath)
+	key, err := url.PathUnescape(escaped)
+	if err != nil || key == "" || escaped == r.URL.EscapedPath() {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		value, expireUnixNanosecondDateTime, exist := p.cache.Get(key)
+		if !exist {
+			value, expireUnixNanosecondDateTime, exist, err = p.load(r.Context(), key)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+		}
+
+		if !exist {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set(HeaderExpire, strconv.FormatInt(expireUnixNanosecondDateTime, 10))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(value)
+	case http.MethodPut:
+		expireUnixNanosecondDateTime, err := strconv.ParseInt(r.Header.Get(HeaderExpire), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid expire", http.StatusBadRequest)
+			return
+		}
+
+		value, err := io.ReadAll(http.MaxBytesReader(w, r.Body, p.opts.maxBodySize))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p.cache.SetByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		p.cache.Delete(key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
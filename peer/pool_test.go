@@ -0,0 +1,196 @@
+package peer
+
+import (
+	"context"
+	"github.com/hunterhug/gocache"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testNode struct {
+	pool   *Pool
+	cache  gocache.Cache
+	server *httptest.Server
+	loads  map[string]int
+}
+
+// newTestCluster start n nodes know each other, the getter of every node record the loaded keys
+func newTestCluster(t *testing.T, n int, opts ...Option) []*testNode {
+	var locker sync.Mutex
+	nodes := make([]*testNode, n)
+	urls := make([]string, n)
+	for i := range nodes {
+		node := &testNode{cache: gocache.New(), server: httptest.NewUnstartedServer(nil), loads: make(map[string]int)}
+		urls[i] = "http://" + node.server.Listener.Addr().String()
+		getter := func(ctx context.Context, key string) ([]byte, time.Duration, error) {
+			locker.Lock()
+			node.loads[key]++
+			locker.Unlock()
+			return []byte("value of " + key), time.Minute, nil
+		}
+
+		node.pool = NewPool(urls[i], node.cache, append(opts, WithGetter(getter))...)
+		node.server.Config.Handler = node.pool
+		node.server.Start()
+		nodes[i] = node
+	}
+
+	for _, node := range nodes {
+		node.pool.AddPeers(urls...)
+	}
+
+	t.Cleanup(func() {
+		for _, node := range nodes {
+			node.server.Close()
+			node.cache.ShutDown()
+		}
+	})
+	return nodes
+}
+
+func (n *testNode) url() string {
+	return "http://" + n.server.Listener.Addr().String()
+}
+
+// ownedBy find a key owned by the node
+func ownedBy(t *testing.T, pool *Pool, node *testNode) string {
+	for i := 0; i < 1000; i++ {
+		key := "key:" + strconv.Itoa(i)
+		if pool.Owner(key) == node.url() {
+			return key
+		}
+	}
+
+	t.Fatalf("no key owned by %s", node.url())
+	return ""
+}
+
+func TestPoolGet(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	a, b, c := nodes[0], nodes[1], nodes[2]
+	if len(a.pool.Peers()) != 3 {
+		t.Fatalf("peers should be 3, but %v", a.pool.Peers())
+	}
+
+	key := ownedBy(t, a.pool, b)
+	for _, node := range []*testNode{a, c, b, a} {
+		value, _, exist, err := node.pool.Get(context.Background(), key)
+		if err != nil || !exist || string(value) != "value of "+key {
+			t.Fatalf("get %s should return the loaded value, but %q %v %v", key, value, exist, err)
+		}
+	}
+
+	if b.loads[key] != 1 || a.loads[key] != 0 || c.loads[key] != 0 {
+		t.Fatalf("key should be loaded once by the owner, but %v %v %v", a.loads, b.loads, c.loads)
+	}
+
+	// not mirrored
+	if _, _, exist := a.cache.Get(key); exist {
+		t.Fatalf("key should not be kept in a without mirror")
+	}
+}
+
+func TestPoolMirror(t *testing.T) {
+	nodes := newTestCluster(t, 2, WithMirrorTTL(time.Second))
+	a, b := nodes[0], nodes[1]
+
+	key := ownedBy(t, a.pool, b)
+	if _, _, _, err := a.pool.Get(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	_, expire, exist := a.cache.Get(key)
+	if !exist || expire > time.Now().Add(time.Second).UnixNano() {
+		t.Fatalf("key should be mirrored in a for at most 1s, but %v %d", exist, expire)
+	}
+
+	if _, ownerExpire, _ := b.cache.Get(key); ownerExpire <= expire {
+		t.Fatalf("the owner should keep the key longer than the mirror")
+	}
+}
+
+func TestPoolSetDelete(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	a, b, c := nodes[0], nodes[1], nodes[2]
+
+	key := ownedBy(t, a.pool, b)
+	if err := a.pool.Set(context.Background(), key, []byte("set by a"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, _, _ := b.cache.Get(key); string(value) != "set by a" {
+		t.Fatalf("key should be set in the owner, but %q", value)
+	}
+
+	if value, _, _, _ := c.pool.Get(context.Background(), key); string(value) != "set by a" {
+		t.Fatalf("c should get the value set by a, but %q", value)
+	}
+
+	if err := c.pool.Delete(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, exist := b.cache.Get(key); exist {
+		t.Fatalf("key should be deleted in the owner")
+	}
+}
+
+func TestPoolMaxBodySize(t *testing.T) {
+	nodes := newTestCluster(t, 2, WithMaxBodySize(4))
+	a, b := nodes[0], nodes[1]
+
+	key := ownedBy(t, a.pool, b)
+	if err := a.pool.Set(context.Background(), key, []byte("12345"), time.Minute); err == nil || !strings.Contains(err.Error(), "413") {
+		t.Fatalf("the value bigger than the max body size should be rejected, but %v", err)
+	}
+
+	if _, _, exist := b.cache.Get(key); exist {
+		t.Fatalf("the rejected value should not be set in the owner")
+	}
+
+	if err := a.pool.Set(context.Background(), key, []byte("1234"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolRemovePeer(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	a, b := nodes[0], nodes[1]
+
+	key := ownedBy(t, a.pool, b)
+	a.pool.RemovePeers(b.url())
+	if a.pool.Owner(key) == b.url() || len(a.pool.Peers()) != 2 {
+		t.Fatalf("key should move to the other peers after b removed")
+	}
+
+	if _, _, _, err := a.pool.Get(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.loads[key] != 0 {
+		t.Fatalf("removed peer should not be requested")
+	}
+
+	a.pool.AddPeers(b.url() + "/")
+	if a.pool.Owner(key) != b.url() {
+		t.Fatalf("key should move back to b after added")
+	}
+}
+
+func TestPoolPeerDown(t *testing.T) {
+	nodes := newTestCluster(t, 2)
+	a, b := nodes[0], nodes[1]
+
+	key := ownedBy(t, a.pool, b)
+	b.server.Close()
+
+	// load locally when the owner can not be reached
+	value, _, exist, err := a.pool.Get(context.Background(), key)
+	if err != nil || !exist || string(value) != "value of "+key || a.loads[key] != 1 {
+		t.Fatalf("key should be loaded by a when b down, but %q %v %v", value, exist, err)
+	}
+}
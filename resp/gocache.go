@@ -0,0 +1,270 @@
+package resp
+
+import (
+	"bytes"
+	"github.com/hunterhug/gocache"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writeEntry write the value and the expire time as an array, null if not exist
+func writeEntry(w *Writer, value []byte, expireUnixNanosecondDateTime int64, exist bool) {
+	if !exist {
+		w.WriteNull()
+		return
+	}
+
+	w.WriteArrayLength(2)
+	w.WriteBulk(value)
+	w.WriteInt(expireUnixNanosecondDateTime)
+}
+
+// gocacheGet GOCACHE.GET key, reply [value, expire unix nanosecond]
+func (s *Server) gocacheGet(w *Writer, args [][]byte) {
+	value, expireUnixNanosecondDateTime, exist := s.Cache.Get(string(args[1]))
+	writeEntry(w, value, expireUnixNanosecondDateTime, exist)
+}
+
+// gocacheSet GOCACHE.SET key value expire-unix-nanosecond
+func (s *Server) gocacheSet(w *Writer, args [][]byte) {
+	expireUnixNanosecondDateTime, ok := parseInt(args[3])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.Cache.SetByExpireUnixNanosecondDateTime(string(args[1]), args[2], expireUnixNanosecondDateTime)
+	w.WriteSimpleString("OK")
+}
+
+// gocacheSetIf GOCACHE.SETIF key value ttl-nanosecond NX|XX|GET, reply as SET with the condition
+func (s *Server) gocacheSetIf(w *Writer, args [][]byte) {
+	ttl, ok := parseInt(args[3])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.setIf(w, string(args[1]), args[2], time.Duration(ttl), strings.ToUpper(string(args[4])))
+}
+
+// gocacheCAS GOCACHE.CAS key old new, reply 1 if swapped, 0 if the value is not old
+func (s *Server) gocacheCAS(w *Writer, args [][]byte) {
+	if s.Cache.CompareAndSwap(string(args[1]), args[2], args[3]) {
+		w.WriteInt(1)
+		return
+	}
+
+	w.WriteInt(0)
+}
+
+// gocacheTTL GOCACHE.TTL key, reply the remaining nanosecond, -1 if the key never expire, -2 if not exist
+func (s *Server) gocacheTTL(w *Writer, args [][]byte) {
+	w.WriteInt(int64(s.remaining(args[1])))
+}
+
+// gocacheExpireAt GOCACHE.EXPIREAT key expire-unix-nanosecond, gocache.NeverExpire keep the key forever,
+// reply 1 if set, 0 if the key not exist
+func (s *Server) gocacheExpireAt(w *Writer, args [][]byte) {
+	expireUnixNanosecondDateTime, ok := parseInt(args[2])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	writeBool(w, s.Cache.ExpireAt(string(args[1]), time.Unix(0, expireUnixNanosecondDateTime)))
+}
+
+// gocacheOldest GOCACHE.OLDEST, reply [key, expire unix nanosecond]
+func (s *Server) gocacheOldest(w *Writer, args [][]byte) {
+	key, expireUnixNanosecondDateTime, exist := s.Cache.GetOldestKey()
+	writeEntry(w, []byte(key), expireUnixNanosecondDateTime, exist)
+}
+
+// gocacheIndex GOCACHE.INDEX index, reply [value, expire unix nanosecond]
+func (s *Server) gocacheIndex(w *Writer, args [][]byte) {
+	index, ok := parseInt(args[1])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	value, expireUnixNanosecondDateTime, exist := s.Cache.Index(int(index))
+	writeEntry(w, value, expireUnixNanosecondDateTime, exist)
+}
+
+// gocacheSetTags GOCACHE.SETTAGS key value ttl-nanosecond tag [tag ...]
+func (s *Server) gocacheSetTags(w *Writer, args [][]byte) {
+	ttl, ok := parseInt(args[3])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	tags := make([]string, 0, len(args)-4)
+	for _, tag := range args[4:] {
+		tags = append(tags, string(tag))
+	}
+
+	s.Cache.SetWithTags(string(args[1]), args[2], time.Duration(ttl), tags...)
+	w.WriteSimpleString("OK")
+}
+
+// gocacheSetSliding GOCACHE.SETSLIDING key value sliding-nanosecond max-lifetime-nanosecond, 0 max lifetime means no limit
+func (s *Server) gocacheSetSliding(w *Writer, args [][]byte) {
+	sliding, ok := parseInt(args[3])
+	maxLifetime, maxOk := parseInt(args[4])
+	if !ok || !maxOk {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.Cache.SetSliding(string(args[1]), args[2], time.Duration(sliding), time.Duration(maxLifetime))
+	w.WriteSimpleString("OK")
+}
+
+// gocacheDelTag GOCACHE.DELTAG tag, delete the keys with the tag, reply the number of deleted keys
+func (s *Server) gocacheDelTag(w *Writer, args [][]byte) {
+	w.WriteInt(int64(s.Cache.InvalidateTag(string(args[1]))))
+}
+
+// gocacheIncrBy GOCACHE.INCRBY key delta ttl-nanosecond, the ttl is used when the key is created
+func (s *Server) gocacheIncrBy(w *Writer, args [][]byte) {
+	delta, ok := parseInt(args[2])
+	ttl, ttlOk := parseInt(args[3])
+	if !ok || !ttlOk {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.addInt(w, string(args[1]), delta, time.Duration(ttl))
+}
+
+// gocacheIncrByFloat GOCACHE.INCRFLOAT key delta ttl-nanosecond, the ttl is used when the key is created
+func (s *Server) gocacheIncrByFloat(w *Writer, args [][]byte) {
+	delta, ok := parseFloat(args[2])
+	if !ok {
+		w.WriteError(errNotFloat)
+		return
+	}
+
+	ttl, ok := parseInt(args[3])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.addFloat(w, string(args[1]), delta, time.Duration(ttl))
+}
+
+// gocacheDelMatch GOCACHE.DELMATCH pattern, delete the keys matching the glob pattern, reply the number of deleted keys
+func (s *Server) gocacheDelMatch(w *Writer, args [][]byte) {
+	w.WriteInt(int64(s.Cache.DeleteMatching(string(args[1]))))
+}
+
+// gocacheDelRegexp GOCACHE.DELREGEXP expr, delete the keys matching the regular expression, reply the number of deleted keys
+func (s *Server) gocacheDelRegexp(w *Writer, args [][]byte) {
+	re, err := regexp.Compile(string(args[1]))
+	if err != nil {
+		w.WriteError("ERR " + err.Error())
+		return
+	}
+
+	w.WriteInt(int64(s.Cache.DeleteMatchingRegexp(re)))
+}
+
+// writeEntries write the entries as a flat array of key, value and expire unix nanosecond
+func writeEntries(w *Writer, entries []gocache.Entry) {
+	w.WriteArrayLength(3 * len(entries))
+	for _, e := range entries {
+		w.WriteBulkString(e.Key)
+		w.WriteBulk(e.Value)
+		w.WriteInt(e.ExpireUnixNanosecondDateTime)
+	}
+}
+
+// gocacheScanRange GOCACHE.SCANRANGE start end limit, reply [key, value, expire unix nanosecond, ...] sorted by key
+func (s *Server) gocacheScanRange(w *Writer, args [][]byte) {
+	limit, ok := parseInt(args[3])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	writeEntries(w, s.Cache.ScanRange(string(args[1]), string(args[2]), int(limit)))
+}
+
+// gocacheScanPrefix GOCACHE.SCANPREFIX prefix limit, reply [key, value, expire unix nanosecond, ...] sorted by key
+func (s *Server) gocacheScanPrefix(w *Writer, args [][]byte) {
+	limit, ok := parseInt(args[2])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	writeEntries(w, s.Cache.ScanPrefix(string(args[1]), int(limit)))
+}
+
+func (s *Server) gocacheMemory(w *Writer, args [][]byte) {
+	w.WriteInt(s.Cache.MemoryUsage())
+}
+
+// gocacheStats GOCACHE.STATS, reply the name and value pairs
+func (s *Server) gocacheStats(w *Writer, args [][]byte) {
+	type pair struct {
+		name  string
+		value int64
+	}
+
+	stats := s.Cache.Stats()
+	pairs := []pair{
+		{"hits", int64(stats.Hits)},
+		{"misses", int64(stats.Misses)},
+		{"sets", int64(stats.Sets)},
+		{"deletes", int64(stats.Deletes)},
+		{"lazy_expirations", int64(stats.LazyExpirations)},
+		{"janitor_expirations", int64(stats.JanitorExpirations)},
+		{"capacity_evictions", int64(stats.CapacityEvictions)},
+		{"size", int64(stats.Size)},
+		{"janitor_runs", int64(stats.JanitorRuns)},
+		{"janitor_run_duration", int64(stats.JanitorRunDuration)},
+	}
+
+	for i, n := range stats.JanitorRunDurationBuckets {
+		pairs = append(pairs, pair{"janitor_run_duration_bucket_" + strconv.Itoa(i), int64(n)})
+	}
+
+	w.WriteArrayLength(2 * len(pairs))
+	for _, p := range pairs {
+		w.WriteBulkString(p.name)
+		w.WriteInt(p.value)
+	}
+}
+
+func (s *Server) gocacheResetStats(w *Writer, args [][]byte) {
+	s.Cache.ResetStats()
+	w.WriteSimpleString("OK")
+}
+
+// gocacheSnapshot GOCACHE.SNAPSHOT, reply the snapshot written by SaveSnapshot
+func (s *Server) gocacheSnapshot(w *Writer, args [][]byte) {
+	var buf bytes.Buffer
+	if err := s.Cache.SaveSnapshot(&buf); err != nil {
+		w.WriteError("ERR " + err.Error())
+		return
+	}
+
+	w.WriteBulk(buf.Bytes())
+}
+
+// gocacheRestore GOCACHE.RESTORE snapshot, load the snapshot by LoadSnapshot
+func (s *Server) gocacheRestore(w *Writer, args [][]byte) {
+	if err := s.Cache.LoadSnapshot(bytes.NewReader(args[1])); err != nil {
+		w.WriteError("ERR " + err.Error())
+		return
+	}
+
+	w.WriteSimpleString("OK")
+}
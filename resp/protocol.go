@@ -0,0 +1,327 @@
+// Package resp serve gocache.Cache over the redis serialization protocol RESP2
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+)
+
+const (
+	maxBulkLength   = 512 << 20
+	maxArrayLength  = 1 << 20
+	maxInlineLength = 64 << 10
+	maxArrayDepth   = 32
+
+	// the length header is not trusted, the bigger bulk and array grow as the data arrive
+	preallocBulkLength  = 64 << 10
+	preallocArrayLength = 1024
+)
+
+var ErrProtocol = errors.New("resp: protocol error")
+
+// Value a RESP2 value
+type Value struct {
+	Type  byte // one of '+', '-', ':', '$', '*'
+	Str   []byte
+	Int   int64
+	Array []Value
+	// Null is true for the null bulk string and the null array
+	Null bool
+}
+
+// Err return the error of the error reply, nil for other types
+func (v Value) Err() error {
+	if v.Type == '-' {
+		return Error(v.Str)
+	}
+
+	return nil
+}
+
+// Error the error reply
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Reader read RESP2 values
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Buffered return the number of bytes can be read without blocking, the pipelined commands are buffered
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+func (r *Reader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if err == nil {
+			line = append(line, chunk...)
+			break
+		}
+
+		if err != bufio.ErrBufferFull {
+			return nil, err
+		}
+
+		line = append(line, chunk...)
+		if len(line) > maxInlineLength {
+			return nil, ErrProtocol
+		}
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, ErrProtocol
+	}
+
+	return line[:len(line)-2], nil
+}
+
+func parseLength(b []byte, max int64) (int64, error) {
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || n < -1 || n > max {
+		return 0, ErrProtocol
+	}
+
+	return n, nil
+}
+
+// ReadValue read one value, the arrays nested deeper than 32 are rejected
+func (r *Reader) ReadValue() (Value, error) {
+	return r.readValue(0)
+}
+
+func (r *Reader) readValue(depth int) (Value, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return Value{}, err
+	}
+
+	if len(line) == 0 {
+		return Value{}, ErrProtocol
+	}
+
+	v := Value{Type: line[0]}
+	switch v.Type {
+	case '+', '-':
+		v.Str = line[1:]
+	case ':':
+		v.Int, err = strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return Value{}, ErrProtocol
+		}
+	case '$':
+		n, err := parseLength(line[1:], maxBulkLength)
+		if err != nil {
+			return Value{}, err
+		}
+
+		if n == -1 {
+			v.Null = true
+			return v, nil
+		}
+
+		if v.Str, err = r.readBulk(n); err != nil {
+			return Value{}, err
+		}
+	case '*':
+		n, err := parseLength(line[1:], maxArrayLength)
+		if err != nil {
+			return Value{}, err
+		}
+
+		if n == -1 {
+			v.Null = true
+			return v, nil
+		}
+
+		if depth >= maxArrayDepth {
+			return Value{}, ErrProtocol
+		}
+
+		v.Array = make([]Value, 0, min(n, preallocArrayLength))
+		for i := int64(0); i < n; i++ {
+			elem, err := r.readValue(depth + 1)
+			if err != nil {
+				return Value{}, err
+			}
+			v.Array = append(v.Array, elem)
+		}
+	default:
+		return Value{}, ErrProtocol
+	}
+
+	return v, nil
+}
+
+// readBulk read the bulk string of length n and the tailing CRLF
+func (r *Reader) readBulk(n int64) ([]byte, error) {
+	if n <= preallocBulkLength {
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r.r, b); err != nil {
+			return nil, err
+		}
+
+		if !bytes.HasSuffix(b, []byte("\r\n")) {
+			return nil, ErrProtocol
+		}
+		return b[:n], nil
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r.r, n+2); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	b := buf.Bytes()
+	if !bytes.HasSuffix(b, []byte("\r\n")) {
+		return nil, ErrProtocol
+	}
+	return b[:n], nil
+}
+
+// readArgs read the array of bulk strings after the '*', the nested arrays are rejected
+func (r *Reader) readArgs() ([][]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := parseLength(line[1:], maxArrayLength)
+	if err != nil || n == -1 {
+		return nil, err
+	}
+
+	args := make([][]byte, 0, min(n, preallocArrayLength))
+	for i := int64(0); i < n; i++ {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 || line[0] != '$' {
+			return nil, ErrProtocol
+		}
+
+		length, err := parseLength(line[1:], maxBulkLength)
+		if err != nil || length == -1 {
+			return nil, ErrProtocol
+		}
+
+		arg, err := r.readBulk(length)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+// ReadCommand read a command sent as an array of bulk strings, or an inline command split by spaces
+func (r *Reader) ReadCommand() ([][]byte, error) {
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if b[0] != '*' {
+			line, err := r.readLine()
+			if err != nil {
+				return nil, err
+			}
+
+			args := bytes.Fields(line)
+			if len(args) == 0 {
+				continue
+			}
+			return args, nil
+		}
+
+		args, err := r.readArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+		return args, nil
+	}
+}
+
+// Writer write RESP2 values
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *Writer) WriteSimpleString(s string) {
+	w.w.WriteByte('+')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteError(s string) {
+	w.w.WriteByte('-')
+	w.w.WriteString(s)
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteInt(n int64) {
+	w.w.WriteByte(':')
+	w.w.WriteString(strconv.FormatInt(n, 10))
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteBulk(b []byte) {
+	w.w.WriteByte('$')
+	w.w.WriteString(strconv.Itoa(len(b)))
+	w.w.WriteString("\r\n")
+	w.w.Write(b)
+	w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteBulkString(s string) {
+	w.WriteBulk([]byte(s))
+}
+
+func (w *Writer) WriteNull() {
+	w.w.WriteString("$-1\r\n")
+}
+
+func (w *Writer) WriteArrayLength(n int) {
+	w.w.WriteByte('*')
+	w.w.WriteString(strconv.Itoa(n))
+	w.w.WriteString("\r\n")
+}
+
+// WriteCommand write the command as an array of bulk strings, used by the clients
+func (w *Writer) WriteCommand(args ...[]byte) {
+	w.WriteArrayLength(len(args))
+	for _, arg := range args {
+		w.WriteBulk(arg)
+	}
+}
@@ -0,0 +1,693 @@
+package resp
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/hunterhug/gocache"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	errSyntax        = "ERR syntax error"
+	errNotInteger    = "ERR value is not an integer or out of range"
+	errNotFloat      = "ERR value is not a valid float"
+	errOverflow      = "ERR increment or decrement would overflow"
+	defaultScanCount = 10
+)
+
+// Server serve the cache over RESP2, the pipelined commands are replied in one write
+type Server struct {
+	Cache gocache.Cache
+	// DefaultTTL used when SET without expire time, 0 means never expire
+	DefaultTTL time.Duration
+
+	locker    sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+var ErrServerClosed = errors.New("resp: server closed")
+
+// ListenAndServe listen on the tcp addr and serve
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accept connections on the listener until it is closed, return ErrServerClosed after Close
+func (s *Server) Serve(l net.Listener) error {
+	if !s.track(l, nil) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l, nil)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		if !s.track(nil, conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+// Close close the listeners and the connections
+func (s *Server) Close() error {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	return nil
+}
+
+func (s *Server) isClosed() bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return s.closed
+}
+
+func (s *Server) track(l net.Listener, conn net.Conn) bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.closed {
+		return false
+	}
+
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[net.Conn]struct{})
+	}
+
+	if l != nil {
+		s.listeners[l] = struct{}{}
+	}
+
+	if conn != nil {
+		s.conns[conn] = struct{}{}
+	}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener, conn net.Conn) {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if l != nil {
+		delete(s.listeners, l)
+	}
+
+	if conn != nil {
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.untrack(nil, conn)
+	defer conn.Close()
+
+	r := NewReader(conn)
+	w := NewWriter(conn)
+	for {
+		args, err := r.ReadCommand()
+		if err != nil {
+			if err == ErrProtocol {
+				w.WriteError("ERR Protocol error")
+				w.Flush()
+			}
+			return
+		}
+
+		quit := s.do(w, args)
+
+		// flush after the pipelined commands are all handled
+		if quit || r.Buffered() == 0 {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+type command struct {
+	// arity is the number of args include the command name, -n means at least n
+	arity   int
+	handler func(s *Server, w *Writer, args [][]byte)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"ping":      {-1, (*Server).ping},
+		"echo":      {2, (*Server).echo},
+		"quit":      {1, nil},
+		"command":   {-1, (*Server).command},
+		"select":    {2, (*Server).selectDB},
+		"get":       {2, (*Server).get},
+		"set":       {-3, (*Server).set},
+		"getset":    {3, (*Server).getSet},
+		"getdel":    {2, (*Server).getDel},
+		"del":       {-2, (*Server).del},
+		"exists":    {-2, (*Server).exists},
+		"ttl":       {2, (*Server).ttl},
+		"pttl":      {2, (*Server).pttl},
+		"expire":    {3, (*Server).expire},
+		"pexpire":   {3, (*Server).pexpire},
+		"expireat":  {3, (*Server).expireAt},
+		"pexpireat": {3, (*Server).pexpireAt},
+		"persist":   {2, (*Server).persist},
+		"keys":      {2, (*Server).keys},
+		"scan":      {-2, (*Server).scan},
+		"dbsize":    {1, (*Server).dbSize},
+
+		// the counters created by the commands expire after DefaultTTL
+		"incr":        {2, (*Server).incr},
+		"decr":        {2, (*Server).decr},
+		"incrby":      {3, (*Server).incrBy},
+		"decrby":      {3, (*Server).decrBy},
+		"incrbyfloat": {3, (*Server).incrByFloat},
+
+		// the gocache commands keep the nanosecond expire time, used by the go client
+		"gocache.get":        {2, (*Server).gocacheGet},
+		"gocache.set":        {4, (*Server).gocacheSet},
+		"gocache.setif":      {5, (*Server).gocacheSetIf},
+		"gocache.cas":        {4, (*Server).gocacheCAS},
+		"gocache.ttl":        {2, (*Server).gocacheTTL},
+		"gocache.expireat":   {3, (*Server).gocacheExpireAt},
+		"gocache.oldest":     {1, (*Server).gocacheOldest},
+		"gocache.index":      {2, (*Server).gocacheIndex},
+		"gocache.settags":    {-5, (*Server).gocacheSetTags},
+		"gocache.setsliding": {5, (*Server).gocacheSetSliding},
+		"gocache.deltag":     {2, (*Server).gocacheDelTag},
+		"gocache.incrby":     {4, (*Server).gocacheIncrBy},
+		"gocache.incrfloat":  {4, (*Server).gocacheIncrByFloat},
+		"gocache.delmatch":   {2, (*Server).gocacheDelMatch},
+		"gocache.delregexp":  {2, (*Server).gocacheDelRegexp},
+		"gocache.scanrange":  {4, (*Server).gocacheScanRange},
+		"gocache.scanprefix": {3, (*Server).gocacheScanPrefix},
+		"gocache.memory":     {1, (*Server).gocacheMemory},
+		"gocache.stats":      {1, (*Server).gocacheStats},
+		"gocache.resetstats": {1, (*Server).gocacheResetStats},
+		"gocache.snapshot":   {1, (*Server).gocacheSnapshot},
+		"gocache.restore":    {2, (*Server).gocacheRestore},
+	}
+}
+
+// do handle one command, return true when the connection should be closed
+func (s *Server) do(w *Writer, args [][]byte) (quit bool) {
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		w.WriteError("ERR unknown command '" + string(args[0]) + "'")
+		return false
+	}
+
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		w.WriteError("ERR wrong number of arguments for '" + name + "' command")
+		return false
+	}
+
+	if name == "quit" {
+		w.WriteSimpleString("OK")
+		return true
+	}
+
+	cmd.handler(s, w, args)
+	return false
+}
+
+func (s *Server) ping(w *Writer, args [][]byte) {
+	switch len(args) {
+	case 1:
+		w.WriteSimpleString("PONG")
+	case 2:
+		w.WriteBulk(args[1])
+	default:
+		w.WriteError("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (s *Server) echo(w *Writer, args [][]byte) {
+	w.WriteBulk(args[1])
+}
+
+// command reply empty for redis-cli
+func (s *Server) command(w *Writer, args [][]byte) {
+	w.WriteArrayLength(0)
+}
+
+func (s *Server) selectDB(w *Writer, args [][]byte) {
+	if string(args[1]) != "0" {
+		w.WriteError("ERR DB index is out of range")
+		return
+	}
+
+	w.WriteSimpleString("OK")
+}
+
+func (s *Server) get(w *Writer, args [][]byte) {
+	value, _, exist := s.Cache.Get(string(args[1]))
+	if !exist {
+		w.WriteNull()
+		return
+	}
+
+	w.WriteBulk(value)
+}
+
+func parseInt(b []byte) (int64, bool) {
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	return n, err == nil
+}
+
+// set SET key value [NX | XX | GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds]
+func (s *Server) set(w *Writer, args [][]byte) {
+	key, value := string(args[1]), args[2]
+	expireUnixNanosecondDateTime := int64(0)
+	condition := ""
+	for i := 3; i < len(args); i++ {
+		option := strings.ToUpper(string(args[i]))
+		switch option {
+		case "NX", "XX", "GET":
+			if condition != "" {
+				w.WriteError(errSyntax)
+				return
+			}
+			condition = option
+		case "EX", "PX", "EXAT", "PXAT":
+			if expireUnixNanosecondDateTime != 0 || i+1 >= len(args) {
+				w.WriteError(errSyntax)
+				return
+			}
+
+			n, ok := parseInt(args[i+1])
+			if !ok {
+				w.WriteError(errNotInteger)
+				return
+			}
+
+			unit := time.Second
+			if option == "PX" || option == "PXAT" {
+				unit = time.Millisecond
+			}
+
+			expire, ok := expireUnixNano(n, unit, option == "EX" || option == "PX")
+			if n <= 0 || !ok {
+				w.WriteError("ERR invalid expire time in 'set' command")
+				return
+			}
+
+			i++
+			expireUnixNanosecondDateTime = expire
+		default:
+			w.WriteError(errSyntax)
+			return
+		}
+	}
+
+	if expireUnixNanosecondDateTime == 0 {
+		expireUnixNanosecondDateTime = gocache.ExpireAfter(s.defaultTTL())
+	}
+
+	if condition != "" {
+		s.setIf(w, key, value, time.Duration(expireUnixNanosecondDateTime-time.Now().UnixNano()), condition)
+		return
+	}
+
+	s.Cache.SetByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+	w.WriteSimpleString("OK")
+}
+
+// setIf set the key by the condition NX, XX or GET, reply OK or null for NX and XX, the old value or null for GET
+func (s *Server) setIf(w *Writer, key string, value []byte, expireTime time.Duration, condition string) {
+	set := false
+	switch condition {
+	case "NX":
+		set = s.Cache.SetIfAbsent(key, value, expireTime)
+	case "XX":
+		set = s.Cache.SetIfPresent(key, value, expireTime)
+	case "GET":
+		old, exist := s.Cache.GetAndSet(key, value, expireTime)
+		if !exist {
+			w.WriteNull()
+			return
+		}
+
+		w.WriteBulk(old)
+		return
+	default:
+		w.WriteError(errSyntax)
+		return
+	}
+
+	if !set {
+		w.WriteNull()
+		return
+	}
+
+	w.WriteSimpleString("OK")
+}
+
+// getSet GETSET key value, the key expire after DefaultTTL
+func (s *Server) getSet(w *Writer, args [][]byte) {
+	s.setIf(w, string(args[1]), args[2], s.defaultTTL(), "GET")
+}
+
+// writeNumericError write the error of the counters
+func writeNumericError(w *Writer, err error) {
+	switch {
+	case errors.Is(err, gocache.ErrNotInteger):
+		w.WriteError(errNotInteger)
+	case errors.Is(err, gocache.ErrNotFloat):
+		w.WriteError(errNotFloat)
+	case errors.Is(err, gocache.ErrOverflow):
+		w.WriteError(errOverflow)
+	default:
+		w.WriteError("ERR " + err.Error())
+	}
+}
+
+// addInt add delta to the key and reply the new value
+func (s *Server) addInt(w *Writer, key string, delta int64, ttlIfCreated time.Duration) {
+	n, err := s.Cache.Incr(key, delta, ttlIfCreated)
+	if err != nil {
+		writeNumericError(w, err)
+		return
+	}
+
+	w.WriteInt(n)
+}
+
+func (s *Server) incr(w *Writer, args [][]byte) {
+	s.addInt(w, string(args[1]), 1, s.defaultTTL())
+}
+
+func (s *Server) decr(w *Writer, args [][]byte) {
+	s.addInt(w, string(args[1]), -1, s.defaultTTL())
+}
+
+// incrBy INCRBY key delta
+func (s *Server) incrBy(w *Writer, args [][]byte) {
+	delta, ok := parseInt(args[2])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.addInt(w, string(args[1]), delta, s.defaultTTL())
+}
+
+// decrBy DECRBY key delta
+func (s *Server) decrBy(w *Writer, args [][]byte) {
+	delta, ok := parseInt(args[2])
+	if !ok || delta == math.MinInt64 {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	s.addInt(w, string(args[1]), -delta, s.defaultTTL())
+}
+
+// addFloat add delta to the key and reply the new value as bulk string
+func (s *Server) addFloat(w *Writer, key string, delta float64, ttlIfCreated time.Duration) {
+	f, err := s.Cache.IncrByFloat(key, delta, ttlIfCreated)
+	if err != nil {
+		writeNumericError(w, err)
+		return
+	}
+
+	w.WriteBulk(strconv.AppendFloat(nil, f, 'f', -1, 64))
+}
+
+func parseFloat(b []byte) (float64, bool) {
+	f, err := strconv.ParseFloat(string(b), 64)
+	return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// incrByFloat INCRBYFLOAT key delta
+func (s *Server) incrByFloat(w *Writer, args [][]byte) {
+	delta, ok := parseFloat(args[2])
+	if !ok {
+		w.WriteError(errNotFloat)
+		return
+	}
+
+	s.addFloat(w, string(args[1]), delta, s.defaultTTL())
+}
+
+// getDel GETDEL key, reply the old value or null
+func (s *Server) getDel(w *Writer, args [][]byte) {
+	old, exist := s.Cache.GetAndDelete(string(args[1]))
+	if !exist {
+		w.WriteNull()
+		return
+	}
+
+	w.WriteBulk(old)
+}
+
+// del count the keys deleted by GetAndDelete, which check and delete in one critical section
+func (s *Server) del(w *Writer, args [][]byte) {
+	var n int64
+	for _, key := range args[1:] {
+		if _, exist := s.Cache.GetAndDelete(string(key)); exist {
+			n++
+		}
+	}
+
+	w.WriteInt(n)
+}
+
+// exists check the key by TTL, which does not count the hits or push the sliding expiration forward
+func (s *Server) exists(w *Writer, args [][]byte) {
+	var n int64
+	for _, key := range args[1:] {
+		if _, exist := s.Cache.TTL(string(key)); exist {
+			n++
+		}
+	}
+
+	w.WriteInt(n)
+}
+
+// defaultTTL return DefaultTTL, gocache.NoExpiration if it is 0
+func (s *Server) defaultTTL() time.Duration {
+	if s.DefaultTTL == 0 {
+		return gocache.NoExpiration
+	}
+
+	return s.DefaultTTL
+}
+
+// remaining return the remaining time of the key, -2 when not exist, -1 when never expire
+func (s *Server) remaining(key []byte) time.Duration {
+	d, exist := s.Cache.TTL(string(key))
+	if !exist {
+		return -2
+	}
+
+	if d == gocache.NoExpiration {
+		return -1
+	}
+
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+func (s *Server) ttl(w *Writer, args [][]byte) {
+	d := s.remaining(args[1])
+	if d < 0 {
+		w.WriteInt(int64(d))
+		return
+	}
+
+	w.WriteInt(int64((d + time.Second/2) / time.Second))
+}
+
+func (s *Server) pttl(w *Writer, args [][]byte) {
+	d := s.remaining(args[1])
+	if d < 0 {
+		w.WriteInt(int64(d))
+		return
+	}
+
+	w.WriteInt(int64((d + time.Millisecond/2) / time.Millisecond))
+}
+
+func (s *Server) expire(w *Writer, args [][]byte) {
+	s.expireBy(w, args, time.Second, true)
+}
+
+func (s *Server) pexpire(w *Writer, args [][]byte) {
+	s.expireBy(w, args, time.Millisecond, true)
+}
+
+func (s *Server) expireAt(w *Writer, args [][]byte) {
+	s.expireBy(w, args, time.Second, false)
+}
+
+func (s *Server) pexpireAt(w *Writer, args [][]byte) {
+	s.expireBy(w, args, time.Millisecond, false)
+}
+
+// expireUnixNano convert n of the unit to the expire unix nanosecond, relative to now if relative, false if overflow
+func expireUnixNano(n int64, unit time.Duration, relative bool) (int64, bool) {
+	if n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit) {
+		return 0, false
+	}
+
+	expire := n * int64(unit)
+	if relative {
+		now := time.Now().UnixNano()
+		if expire > math.MaxInt64-now {
+			return 0, false
+		}
+		expire += now
+	}
+
+	return expire, true
+}
+
+// expireBy set the expire time of the key, delete the key when the time is in the past
+func (s *Server) expireBy(w *Writer, args [][]byte, unit time.Duration, relative bool) {
+	n, ok := parseInt(args[2])
+	if !ok {
+		w.WriteError(errNotInteger)
+		return
+	}
+
+	expire, ok := expireUnixNano(n, unit, relative)
+	if !ok {
+		w.WriteError("ERR invalid expire time in '" + strings.ToLower(string(args[0])) + "' command")
+		return
+	}
+
+	writeBool(w, s.Cache.ExpireAt(string(args[1]), time.Unix(0, expire)))
+}
+
+// persist PERSIST key, remove the expire time of the key, reply 1 if removed
+func (s *Server) persist(w *Writer, args [][]byte) {
+	writeBool(w, s.Cache.Persist(string(args[1])))
+}
+
+// writeBool reply 1 for true, 0 for false
+func writeBool(w *Writer, b bool) {
+	if b {
+		w.WriteInt(1)
+		return
+	}
+
+	w.WriteInt(0)
+}
+
+// keys KEYS pattern, reply the sorted keys not expired
+func (s *Server) keys(w *Writer, args [][]byte) {
+	keys := s.Cache.KeysMatching(string(args[1]))
+	w.WriteArrayLength(len(keys))
+	for _, key := range keys {
+		w.WriteBulkString(key)
+	}
+}
+
+// scan SCAN cursor [MATCH pattern] [COUNT count], walk the sorted keys not expired,
+// the cursor is the hex of the last walked key so the writes between the calls not skip or repeat the other keys,
+// the clients should treat it as an opaque string, 0 start and end the scan
+func (s *Server) scan(w *Writer, args [][]byte) {
+	start := ""
+	if cursor := string(args[1]); cursor != "0" {
+		last, err := hex.DecodeString(cursor)
+		if err != nil {
+			w.WriteError("ERR invalid cursor")
+			return
+		}
+
+		// the smallest key after the last one
+		start = string(last) + "\x00"
+	}
+
+	pattern, count := "*", int64(defaultScanCount)
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			w.WriteError(errSyntax)
+			return
+		}
+
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = string(args[i+1])
+		case "COUNT":
+			n, ok := parseInt(args[i+1])
+			if !ok {
+				w.WriteError(errNotInteger)
+				return
+			}
+
+			if n < 1 {
+				w.WriteError(errSyntax)
+				return
+			}
+			count = n
+		default:
+			w.WriteError(errSyntax)
+			return
+		}
+	}
+
+	entries := s.Cache.ScanRange(start, "", int(count))
+	var matched []string
+	for _, entry := range entries {
+		if gocache.MatchPattern(pattern, entry.Key) {
+			matched = append(matched, entry.Key)
+		}
+	}
+
+	next := "0"
+	if len(entries) == int(count) {
+		next = hex.EncodeToString([]byte(entries[len(entries)-1].Key))
+	}
+
+	w.WriteArrayLength(2)
+	w.WriteBulkString(next)
+	w.WriteArrayLength(len(matched))
+	for _, key := range matched {
+		w.WriteBulkString(key)
+	}
+}
+
+func (s *Server) dbSize(w *Writer, args [][]byte) {
+	w.WriteInt(int64(s.Cache.Size()))
+}
@@ -0,0 +1,404 @@
+package resp
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/hunterhug/gocache"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *Reader
+}
+
+func (c *testClient) send(args ...string) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) read() Value {
+	v, err := c.r.ReadValue()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return v
+}
+
+func (c *testClient) do(args ...string) Value {
+	c.send(args...)
+	return c.read()
+}
+
+func newTestServer(t *testing.T) (*testClient, gocache.Cache, func()) {
+	cache := gocache.New()
+	server := &Server{Cache: cache, DefaultTTL: time.Minute}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go server.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testClient{t: t, conn: conn, r: NewReader(conn)}, cache, func() {
+		conn.Close()
+		server.Close()
+		cache.ShutDown()
+	}
+}
+
+func TestServer(t *testing.T) {
+	c, cache, done := newTestServer(t)
+	defer done()
+
+	if v := c.do("PING"); string(v.Str) != "PONG" {
+		t.Fatalf("ping should return PONG, but %q", v.Str)
+	}
+
+	if v := c.do("SET", "user:1", "a hi"); string(v.Str) != "OK" {
+		t.Fatalf("set should return OK, but %q", v.Str)
+	}
+
+	if v := c.do("GET", "user:1"); string(v.Str) != "a hi" {
+		t.Fatalf("get should return a hi, but %q", v.Str)
+	}
+
+	if v := c.do("GET", "none"); !v.Null {
+		t.Fatalf("get not exist key should return null")
+	}
+
+	if v := c.do("TTL", "user:1"); v.Int != 60 {
+		t.Fatalf("ttl should use default ttl 60, but %d", v.Int)
+	}
+
+	c.do("SET", "user:2", "b hi", "EX", "10")
+	if v := c.do("TTL", "user:2"); v.Int != 10 {
+		t.Fatalf("ttl should be 10, but %d", v.Int)
+	}
+
+	c.do("SET", "user:3", "c hi", "PX", "5000")
+	if v := c.do("PTTL", "user:3"); v.Int <= 4900 || v.Int > 5000 {
+		t.Fatalf("pttl should be about 5000, but %d", v.Int)
+	}
+
+	expire := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	c.do("SET", "order:1", "d hi", "PXAT", strconv.FormatInt(expire.UnixMilli(), 10))
+	if _, e, _ := cache.Get("order:1"); e != expire.UnixNano() {
+		t.Fatalf("pxat should set expire %d, but %d", expire.UnixNano(), e)
+	}
+
+	c.do("SET", "order:2", "e hi", "EXAT", strconv.FormatInt(expire.Unix(), 10))
+	if _, e, _ := cache.Get("order:2"); e != expire.Unix()*int64(time.Second) {
+		t.Fatalf("exat should set expire %d, but %d", expire.Unix()*int64(time.Second), e)
+	}
+
+	if v := c.do("SET", "a", "b", "EX"); v.Type != '-' {
+		t.Fatalf("set without ex value should return error")
+	}
+
+	if v := c.do("SET", "a", "b", "EX", "1", "PX", "1"); v.Type != '-' {
+		t.Fatalf("set with two expire should return error")
+	}
+
+	if v := c.do("SET", "a", "b", "EX", "x"); v.Type != '-' {
+		t.Fatalf("set with bad ex should return error")
+	}
+
+	if v := c.do("GET"); v.Err() == nil {
+		t.Fatalf("get without key should return error")
+	}
+
+	if v := c.do("NOPE"); v.Err() == nil {
+		t.Fatalf("unknown command should return error")
+	}
+
+	if v := c.do("EXISTS", "user:1", "user:2", "none"); v.Int != 2 {
+		t.Fatalf("exists should return 2, but %d", v.Int)
+	}
+
+	// exists does not push the sliding expiration forward
+	cache.SetSliding("sliding", []byte("a"), time.Minute, 0)
+	entries := cache.ScanPrefix("sliding", 0)
+	hits := cache.Stats().Hits
+	if v := c.do("EXISTS", "sliding"); v.Int != 1 || cache.ScanPrefix("sliding", 0)[0].ExpireUnixNanosecondDateTime != entries[0].ExpireUnixNanosecondDateTime || cache.Stats().Hits != hits {
+		t.Fatalf("exists should not touch the sliding key, but %d", v.Int)
+	}
+	cache.Delete("sliding")
+
+	if v := c.do("DBSIZE"); v.Int != 5 {
+		t.Fatalf("dbsize should return 5, but %d", v.Int)
+	}
+
+	v := c.do("KEYS", "user:*")
+	if len(v.Array) != 3 || string(v.Array[0].Str) != "user:1" || string(v.Array[2].Str) != "user:3" {
+		t.Fatalf("keys should return sorted user keys, but %v", v.Array)
+	}
+
+	if v := c.do("KEYS", "order:[!1]"); len(v.Array) != 1 || string(v.Array[0].Str) != "order:2" {
+		t.Fatalf("keys should return order:2, but %v", v.Array)
+	}
+
+	if v := c.do("EXPIRE", "user:1", "100"); v.Int != 1 {
+		t.Fatalf("expire should return 1, but %d", v.Int)
+	}
+
+	if v := c.do("TTL", "user:1"); v.Int != 100 {
+		t.Fatalf("ttl should be 100 after expire, but %d", v.Int)
+	}
+
+	if v := c.do("PERSIST", "user:1"); v.Int != 1 {
+		t.Fatalf("persist should return 1, but %d", v.Int)
+	}
+
+	if v := c.do("TTL", "user:1"); v.Int != -1 {
+		t.Fatalf("ttl of persisted key should be -1, but %d", v.Int)
+	}
+
+	if v := c.do("PERSIST", "user:1"); v.Int != 0 {
+		t.Fatalf("persist key never expire should return 0, but %d", v.Int)
+	}
+
+	if v := c.do("EXPIRE", "user:1", "9223372036854775807"); v.Type != '-' {
+		t.Fatalf("overflowed expire should return error, but %v", v)
+	}
+
+	if v := c.do("SET", "user:1", "a hi", "PX", "9223372036854775807"); v.Type != '-' {
+		t.Fatalf("overflowed set should return error, but %v", v)
+	}
+
+	if v := c.do("EXPIRE", "none", "100"); v.Int != 0 {
+		t.Fatalf("expire not exist key should return 0, but %d", v.Int)
+	}
+
+	if v := c.do("EXPIRE", "user:1", "-1"); v.Int != 1 {
+		t.Fatalf("expire should return 1, but %d", v.Int)
+	}
+
+	if v := c.do("TTL", "user:1"); v.Int != -2 {
+		t.Fatalf("ttl of expired key should be -2, but %d", v.Int)
+	}
+
+	if v := c.do("DEL", "user:2", "user:3", "none"); v.Int != 2 {
+		t.Fatalf("del should return 2, but %d", v.Int)
+	}
+
+	if v := c.do("QUIT"); string(v.Str) != "OK" {
+		t.Fatalf("quit should return OK, but %q", v.Str)
+	}
+}
+
+func TestServerScan(t *testing.T) {
+	c, cache, done := newTestServer(t)
+	defer done()
+
+	for i := 0; i < 25; i++ {
+		cache.Set(fmt.Sprintf("key:%02d", i), []byte("hi"), time.Minute)
+	}
+	cache.Set("other", []byte("hi"), time.Minute)
+	cache.Set("key:expired", []byte("hi"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	if v := c.do("KEYS", "key:*"); len(v.Array) != 25 {
+		t.Fatalf("keys should skip the expired key, but %d", len(v.Array))
+	}
+
+	var keys []string
+	cursor := "0"
+	for {
+		v := c.do("SCAN", cursor, "MATCH", "key:*", "COUNT", "7")
+		if len(v.Array) != 2 {
+			t.Fatalf("scan should return two elements, but %v", v)
+		}
+
+		for _, key := range v.Array[1].Array {
+			keys = append(keys, string(key.Str))
+		}
+
+		// the delete before the cursor should not skip the keys after it
+		if cursor == "0" {
+			cache.Delete("key:00")
+		}
+
+		cursor = string(v.Array[0].Str)
+		if cursor == "0" {
+			break
+		}
+	}
+
+	if len(keys) != 25 || keys[0] != "key:00" || keys[24] != "key:24" {
+		t.Fatalf("scan should return all the keys in order, but %v", keys)
+	}
+
+	if v := c.do("SCAN", "not hex"); v.Type != '-' {
+		t.Fatalf("scan should reject the invalid cursor, but %v", v)
+	}
+}
+
+func TestReadCommand(t *testing.T) {
+	for _, input := range []string{
+		// nested array
+		"*1\r\n*1\r\n$1\r\na\r\n",
+		"*1\r\n:1\r\n",
+	} {
+		if _, err := NewReader(strings.NewReader(input)).ReadCommand(); err != ErrProtocol {
+			t.Fatalf("%q should be rejected, but %v", input, err)
+		}
+	}
+
+	// the length header without the data should not allocate it
+	for _, input := range []string{"*1\r\n$536870912\r\nab", "*1048576\r\n$1\r\na\r\n"} {
+		if _, err := NewReader(strings.NewReader(input)).ReadCommand(); err != io.ErrUnexpectedEOF && err != io.EOF {
+			t.Fatalf("%q should be unexpected eof, but %v", input, err)
+		}
+	}
+
+	big := strings.Repeat("a", 100<<10)
+	args, err := NewReader(strings.NewReader("*2\r\n$3\r\nset\r\n$" + strconv.Itoa(len(big)) + "\r\n" + big + "\r\n")).ReadCommand()
+	if err != nil || len(args) != 2 || string(args[1]) != big {
+		t.Fatalf("big bulk should be read, but %v", err)
+	}
+
+	if _, err := NewReader(strings.NewReader(strings.Repeat("*1\r\n", 40) + ":1\r\n")).ReadValue(); err != ErrProtocol {
+		t.Fatalf("deep nested array should be rejected, but %v", err)
+	}
+}
+
+func TestServerIncr(t *testing.T) {
+	c, _, done := newTestServer(t)
+	defer done()
+
+	if v := c.do("INCR", "counter"); v.Int != 1 {
+		t.Fatalf("incr should create 1, but %d", v.Int)
+	}
+
+	if v := c.do("TTL", "counter"); v.Int != 60 {
+		t.Fatalf("created counter should use default ttl 60, but %d", v.Int)
+	}
+
+	c.do("INCRBY", "counter", "10")
+	c.do("DECR", "counter")
+	if v := c.do("DECRBY", "counter", "3"); v.Int != 7 {
+		t.Fatalf("counter should be 7, but %d", v.Int)
+	}
+
+	if v := c.do("INCRBYFLOAT", "counter", "0.5"); string(v.Str) != "7.5" {
+		t.Fatalf("incr by float should return 7.5, but %q", v.Str)
+	}
+
+	if v := c.do("INCR", "counter"); string(v.Str) != "ERR value is not an integer or out of range" {
+		t.Fatalf("incr float should return error, but %q", v.Str)
+	}
+
+	c.do("SET", "max", "9223372036854775807")
+	if v := c.do("INCR", "max"); string(v.Str) != "ERR increment or decrement would overflow" {
+		t.Fatalf("incr max should overflow, but %q", v.Str)
+	}
+
+	if v := c.do("GOCACHE.INCRBY", "created", "5", "1000000000"); v.Int != 5 {
+		t.Fatalf("gocache.incrby should create 5, but %d", v.Int)
+	}
+
+	if v := c.do("TTL", "created"); v.Int != 1 {
+		t.Fatalf("created counter should expire after 1s, but %d", v.Int)
+	}
+}
+
+func TestServerSetIf(t *testing.T) {
+	c, _, done := newTestServer(t)
+	defer done()
+
+	if v := c.do("SET", "lock", "a", "NX", "EX", "10"); string(v.Str) != "OK" {
+		t.Fatalf("set nx should return OK, but %q", v.Str)
+	}
+
+	if v := c.do("SET", "lock", "b", "NX"); !v.Null {
+		t.Fatalf("set nx existing key should return null, but %q", v.Str)
+	}
+
+	if v := c.do("TTL", "lock"); v.Int != 10 {
+		t.Fatalf("ttl should be 10, but %d", v.Int)
+	}
+
+	if v := c.do("SET", "none", "a", "XX"); !v.Null {
+		t.Fatalf("set xx not exist key should return null, but %q", v.Str)
+	}
+
+	if v := c.do("SET", "lock", "c", "GET"); string(v.Str) != "a" {
+		t.Fatalf("set get should return a, but %q", v.Str)
+	}
+
+	if v := c.do("SET", "lock", "c", "NX", "XX"); string(v.Str) != "ERR syntax error" {
+		t.Fatalf("set nx xx should return syntax error, but %q", v.Str)
+	}
+
+	if v := c.do("GETSET", "lock", "d"); string(v.Str) != "c" {
+		t.Fatalf("getset should return c, but %q", v.Str)
+	}
+
+	if v := c.do("GOCACHE.CAS", "lock", "d", "e"); v.Int != 1 {
+		t.Fatalf("cas should succeed, but %d", v.Int)
+	}
+
+	if v := c.do("GOCACHE.CAS", "lock", "d", "f"); v.Int != 0 {
+		t.Fatalf("cas should fail, but %d", v.Int)
+	}
+
+	if v := c.do("GOCACHE.SETIF", "lock", "g", "1000000000", "xx"); string(v.Str) != "OK" {
+		t.Fatalf("gocache.setif xx should return OK, but %q", v.Str)
+	}
+
+	if v := c.do("PTTL", "lock"); v.Int <= 900 || v.Int > 1000 {
+		t.Fatalf("pttl should be about 1000, but %d", v.Int)
+	}
+
+	if v := c.do("GETDEL", "lock"); string(v.Str) != "g" {
+		t.Fatalf("getdel should return g, but %q", v.Str)
+	}
+
+	if v := c.do("GETDEL", "lock"); !v.Null {
+		t.Fatalf("getdel should return null after delete, but %q", v.Str)
+	}
+}
+
+func TestServerPipeline(t *testing.T) {
+	c, _, done := newTestServer(t)
+	defer done()
+
+	// send all the commands in one write, inline command also work
+	var buf bytes.Buffer
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&buf, "*3\r\n$3\r\nSET\r\n$%d\r\nk%d\r\n$1\r\nv\r\n", len(strconv.Itoa(i))+1, i)
+	}
+	buf.WriteString("DBSIZE\r\n")
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		if v := c.read(); string(v.Str) != "OK" {
+			t.Fatalf("set %d should return OK, but %q", i, v.Str)
+		}
+	}
+
+	if v := c.read(); v.Int != 100 {
+		t.Fatalf("dbsize should return 100, but %d", v.Int)
+	}
+}
@@ -0,0 +1,125 @@
+package gocache
+
+import (
+	"github.com/hunterhug/gocache/algorithm"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry the key value pair returned by the scans, Value is set by Set and Raw is set by SetInterface
+type Entry struct {
+	Key                          string
+	Value                        []byte
+	Raw                          interface{}
+	ExpireUnixNanosecondDateTime int64
+}
+
+// prefixEnd return the smallest key greater than all the keys with the prefix, empty means no upper bound
+func prefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+
+	return ""
+}
+
+// ScanRange return the live keys in [start, end) sorted, empty end means no upper bound,
+// limit <= 0 means no limit, only the subtree from start is walked
+func (c *cache) ScanRange(start, end string, limit int) []Entry {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return nil
+	}
+
+	var entries []Entry
+	now := time.Now().UnixNano()
+	c.treeMap.RangeFrom(start, func(key string, value interface{}) bool {
+		if end != "" && key >= end {
+			return false
+		}
+
+		item := value.(*algorithm.HeapValue).Extra.(*cacheItem)
+		if item.expireUnixNanosecondDateTime > now {
+			entries = append(entries, Entry{
+				Key:                          key,
+				Value:                        item.RawByte,
+				Raw:                          item.Raw,
+				ExpireUnixNanosecondDateTime: item.expireUnixNanosecondDateTime,
+			})
+		}
+
+		return limit <= 0 || len(entries) < limit
+	})
+
+	return entries
+}
+
+// KeysMatching return the live keys matching the redis style glob pattern sorted,
+// only the keys with the literal prefix of the pattern are walked and the values are not copied
+func (c *cache) KeysMatching(pattern string) []string {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return nil
+	}
+
+	var keys []string
+	prefix := patternPrefix(pattern)
+	now := time.Now().UnixNano()
+	c.treeMap.RangeFrom(prefix, func(key string, value interface{}) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+
+		if value.(*algorithm.HeapValue).Extra.(*cacheItem).expireUnixNanosecondDateTime > now && MatchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+		return true
+	})
+
+	return keys
+}
+
+// ScanPrefix return the live keys with the prefix sorted, limit <= 0 means no limit
+func (c *cache) ScanPrefix(prefix string, limit int) []Entry {
+	return c.ScanRange(prefix, prefixEnd(prefix), limit)
+}
+
+// ScanRange merge the sorted keys of all shards
+func (c *shardedCache) ScanRange(start, end string, limit int) []Entry {
+	var entries []Entry
+	for _, s := range c.shards {
+		entries = append(entries, s.ScanRange(start, end, limit)...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries
+}
+
+// KeysMatching merge the sorted keys of all shards
+func (c *shardedCache) KeysMatching(pattern string) []string {
+	var keys []string
+	for _, s := range c.shards {
+		keys = append(keys, s.KeysMatching(pattern)...)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *shardedCache) ScanPrefix(prefix string, limit int) []Entry {
+	return c.ScanRange(prefix, prefixEnd(prefix), limit)
+}
@@ -0,0 +1,81 @@
+package gocache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func scanKeys(entries []Entry) []string {
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestPrefixEnd(t *testing.T) {
+	for prefix, end := range map[string]string{"user:": "user;", "a\xff": "b", "\xff\xff": "", "": ""} {
+		if got := prefixEnd(prefix); got != end {
+			t.Fatalf("prefix end of %q should be %q, but %q", prefix, end, got)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	for name, c := range map[string]Cache{"cache": New(), "sharded": NewSharded(4)} {
+		for _, key := range []string{"user:2:name", "user:1:name", "user:10:age", "user:1:age", "user;", "order:1", "user:"} {
+			c.Set(key, []byte("value of "+key), time.Minute)
+		}
+		c.SetInterface("user:3:tags", []string{"a"}, time.Minute)
+		c.Set("user:1:expired", []byte("expired"), -time.Second)
+
+		if keys := scanKeys(c.ScanPrefix("user:1", 0)); fmt.Sprint(keys) != "[user:10:age user:1:age user:1:name]" {
+			t.Fatalf("%s: scan prefix user:1 wrong: %v", name, keys)
+		}
+
+		if keys := scanKeys(c.ScanPrefix("user:1:", 0)); fmt.Sprint(keys) != "[user:1:age user:1:name]" {
+			t.Fatalf("%s: scan prefix user:1: wrong: %v", name, keys)
+		}
+
+		entries := c.ScanPrefix("user:", 3)
+		if keys := scanKeys(entries); fmt.Sprint(keys) != "[user: user:10:age user:1:age]" {
+			t.Fatalf("%s: scan prefix with limit wrong: %v", name, keys)
+		}
+
+		if string(entries[1].Value) != "value of user:10:age" || entries[1].ExpireUnixNanosecondDateTime <= time.Now().UnixNano() {
+			t.Fatalf("%s: scan entry wrong: %v", name, entries[1])
+		}
+
+		// page from the last key
+		entries = c.ScanRange(entries[2].Key+"\x00", prefixEnd("user:"), 0)
+		if keys := scanKeys(entries); fmt.Sprint(keys) != "[user:1:name user:2:name user:3:tags]" {
+			t.Fatalf("%s: scan next page wrong: %v", name, keys)
+		}
+
+		if entries[2].Raw.([]string)[0] != "a" {
+			t.Fatalf("%s: scan should return the interface value, but %v", name, entries[2])
+		}
+
+		if keys := c.KeysMatching("user:1*"); fmt.Sprint(keys) != "[user:10:age user:1:age user:1:name]" {
+			t.Fatalf("%s: keys matching user:1* wrong: %v", name, keys)
+		}
+
+		if keys := c.KeysMatching("*:name"); fmt.Sprint(keys) != "[user:1:name user:2:name]" {
+			t.Fatalf("%s: keys matching *:name wrong: %v", name, keys)
+		}
+
+		if keys := scanKeys(c.ScanRange("", "user:", 0)); fmt.Sprint(keys) != "[order:1]" {
+			t.Fatalf("%s: scan range wrong: %v", name, keys)
+		}
+
+		if keys := scanKeys(c.ScanRange("user:3", "", 0)); fmt.Sprint(keys) != "[user:3:tags user;]" {
+			t.Fatalf("%s: scan range without end wrong: %v", name, keys)
+		}
+
+		c.ShutDown()
+		if c.ScanPrefix("", 0) != nil {
+			t.Fatalf("%s: scan should return nil after shut down", name)
+		}
+	}
+}
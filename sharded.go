@@ -0,0 +1,241 @@
+package gocache
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"time"
+)
+
+const defaultShardNum = 16
+
+type shardedCache struct {
+	shards []*cache
+	opts   options
+	// stats of the shared janitor
+	janitorStats janitorStats
+}
+
+func divideCeil(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// shard choose the shard by fnv-1a hash of the key
+func (c *shardedCache) shard(key string) *cache {
+	return c.shards[c.shardIndex(key)]
+}
+
+func (c *shardedCache) shardIndex(key string) int {
+	var h uint32 = 2166136261
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+
+	return int(h % uint32(len(c.shards)))
+}
+
+func (c *shardedCache) loopCleanExpireItem() {
+	timer := time.NewTimer(c.opts.cleanInterval)
+	for {
+		select {
+		case <-timer.C:
+			closed := false
+			start := time.Now()
+			for _, s := range c.shards {
+				if s.cleanOlder() {
+					closed = true
+				}
+			}
+			c.janitorStats.observe(time.Since(start))
+
+			if closed {
+				timer.Stop()
+				return
+			}
+			timer.Reset(c.opts.cleanInterval)
+		}
+	}
+}
+
+func (c *shardedCache) ShutDown() {
+	for _, s := range c.shards {
+		s.ShutDown()
+	}
+}
+
+func (c *shardedCache) Set(key string, value []byte, expireTime time.Duration) {
+	c.shard(key).Set(key, value, expireTime)
+}
+
+func (c *shardedCache) SetInterface(key string, value interface{}, expireTime time.Duration) {
+	c.shard(key).SetInterface(key, value, expireTime)
+}
+
+func (c *shardedCache) SetByExpireUnixNanosecondDateTime(key string, value []byte, expireUnixNanosecondDateTime int64) {
+	c.shard(key).SetByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+}
+
+func (c *shardedCache) SetInterfaceByExpireUnixNanosecondDateTime(key string, value interface{}, expireUnixNanosecondDateTime int64) {
+	c.shard(key).SetInterfaceByExpireUnixNanosecondDateTime(key, value, expireUnixNanosecondDateTime)
+}
+
+func (c *shardedCache) Delete(key string) {
+	c.shard(key).Delete(key)
+}
+
+// DeleteMatching each shard delete its matched keys in its own critical section
+func (c *shardedCache) DeleteMatching(pattern string) int {
+	deleted := 0
+	for _, s := range c.shards {
+		deleted += s.DeleteMatching(pattern)
+	}
+
+	return deleted
+}
+
+func (c *shardedCache) DeleteMatchingRegexp(re *regexp.Regexp) int {
+	deleted := 0
+	for _, s := range c.shards {
+		deleted += s.DeleteMatchingRegexp(re)
+	}
+
+	return deleted
+}
+
+func (c *shardedCache) Get(key string) (value []byte, expireUnixNanosecondDateTime int64, exist bool) {
+	return c.shard(key).Get(key)
+}
+
+func (c *shardedCache) GetInterface(key string) (value interface{}, expireUnixNanosecondDateTime int64, exist bool) {
+	return c.shard(key).GetInterface(key)
+}
+
+func (c *shardedCache) GetOrLoad(ctx context.Context, key string, loader Loader) (value []byte, expireUnixNanosecondDateTime int64, err error) {
+	return c.shard(key).GetOrLoad(ctx, key, loader)
+}
+
+func (c *shardedCache) GetInterfaceOrLoad(ctx context.Context, key string, loader InterfaceLoader) (value interface{}, expireUnixNanosecondDateTime int64, err error) {
+	return c.shard(key).GetInterfaceOrLoad(ctx, key, loader)
+}
+
+// GetOldestKey return the oldest key of all shards
+func (c *shardedCache) GetOldestKey() (key string, expireUnixNanosecondDateTime int64, exist bool) {
+	for _, s := range c.shards {
+		k, e, ok := s.GetOldestKey()
+		if ok && (!exist || e < expireUnixNanosecondDateTime) {
+			key, expireUnixNanosecondDateTime, exist = k, e, true
+		}
+	}
+
+	return
+}
+
+func (c *shardedCache) Size() int {
+	size := 0
+	for _, s := range c.shards {
+		size += s.Size()
+	}
+
+	return size
+}
+
+// Index the index go through the shards one by one
+func (c *shardedCache) Index(index int) (value []byte, expireUnixNanosecondDateTime int64, exist bool) {
+	for _, s := range c.shards {
+		size := s.Size()
+		if index < size {
+			return s.Index(index)
+		}
+
+		index -= size
+	}
+
+	return
+}
+
+func (c *shardedCache) IndexInterface(index int) (value interface{}, expireUnixNanosecondDateTime int64, exist bool) {
+	for _, s := range c.shards {
+		size := s.Size()
+		if index < size {
+			return s.IndexInterface(index)
+		}
+
+		index -= size
+	}
+
+	return
+}
+
+// KeyList return the keys of all shards, shard by shard
+func (c *shardedCache) KeyList() []string {
+	keyList := make([]string, 0, c.Size())
+	for _, s := range c.shards {
+		keyList = append(keyList, s.KeyList()...)
+	}
+
+	return keyList
+}
+
+func (c *shardedCache) MemoryUsage() int64 {
+	var memoryUsage int64
+	for _, s := range c.shards {
+		memoryUsage += s.MemoryUsage()
+	}
+
+	return memoryUsage
+}
+
+func (c *shardedCache) OnEvict(f EvictFunc) {
+	for _, s := range c.shards {
+		s.OnEvict(f)
+	}
+}
+
+// SaveSnapshot save all shards into one snapshot
+func (c *shardedCache) SaveSnapshot(w io.Writer) error {
+	var records []snapshotRecord
+	for _, s := range c.shards {
+		records = append(records, s.snapshotRecords()...)
+	}
+
+	return writeSnapshot(w, c.opts.codec, records)
+}
+
+func (c *shardedCache) LoadSnapshot(r io.Reader) error {
+	records, err := readSnapshot(r, c.opts.codec)
+	if err != nil {
+		return err
+	}
+
+	shardRecords := make([][]snapshotRecord, len(c.shards))
+	for _, record := range records {
+		i := c.shardIndex(record.key)
+		shardRecords[i] = append(shardRecords[i], record)
+	}
+
+	for i, s := range c.shards {
+		s.loadRecords(shardRecords[i])
+	}
+
+	return nil
+}
+
+func (c *shardedCache) Stats() Stats {
+	var stats Stats
+	for _, s := range c.shards {
+		s.stats.addTo(&stats)
+		stats.Size += s.Size()
+	}
+
+	c.janitorStats.addTo(&stats)
+	return stats
+}
+
+func (c *shardedCache) ResetStats() {
+	for _, s := range c.shards {
+		s.ResetStats()
+	}
+
+	c.janitorStats.reset()
+}
@@ -0,0 +1,52 @@
+package gocache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewSharded(t *testing.T) {
+	c := NewSharded(8, WithCleanInterval(10*time.Millisecond))
+	defer c.ShutDown()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.Set(fmt.Sprintf("%d-%d", g, i), []byte("hi"), time.Duration(10+i)*time.Second)
+				c.Get(fmt.Sprintf("%d-%d", g, i))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if c.Size() != 800 {
+		t.Fatalf("size should be 800, but %d", c.Size())
+	}
+
+	if len(c.KeyList()) != 800 {
+		t.Fatalf("key list should be 800, but %d", len(c.KeyList()))
+	}
+
+	for i := 0; i < c.Size(); i++ {
+		if _, _, exist := c.Index(i); !exist {
+			t.Fatalf("index %d should exist", i)
+		}
+	}
+
+	c.Set("oldest", []byte("hi"), time.Second)
+	oldK, _, _ := c.GetOldestKey()
+	if oldK != "oldest" {
+		t.Fatalf("oldest key should be oldest, but %s", oldK)
+	}
+
+	c.Set("expired", []byte("hi"), time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if c.Size() != 801 {
+		t.Fatalf("size should be 801, but %d", c.Size())
+	}
+}
@@ -0,0 +1,68 @@
+package gocache
+
+import (
+	"github.com/hunterhug/gocache/algorithm"
+	"time"
+)
+
+// SetSliding set the key expire after slidingExpiration, each Get hit push the expire time forward by slidingExpiration,
+// the key never live longer than maxLifetime from now if it is positive,
+// the sliding expiration is not kept in the snapshot and the append only file and the slides are not logged,
+// so the key is restored with the expire time of the last write and does not slide any more
+func (c *cache) SetSliding(key string, value []byte, slidingExpiration, maxLifetime time.Duration) {
+	item := cacheItem{
+		RawByte: value,
+		cost:    int64(len(key) + len(value)),
+	}
+
+	c.setSliding(key, item, slidingExpiration, maxLifetime)
+}
+
+// SetInterfaceSliding see SetSliding
+func (c *cache) SetInterfaceSliding(key string, value interface{}, slidingExpiration, maxLifetime time.Duration) {
+	item := cacheItem{
+		Raw:         value,
+		isInterface: true,
+		cost:        c.interfaceCost(key, value),
+	}
+
+	c.setSliding(key, item, slidingExpiration, maxLifetime)
+}
+
+func (c *cache) setSliding(key string, value cacheItem, slidingExpiration, maxLifetime time.Duration) {
+	value.sliding = slidingExpiration
+	if maxLifetime > 0 {
+		value.maxExpireUnixNanosecondDateTime = ExpireAfter(maxLifetime)
+	}
+
+	c.setByExpireDateTime(key, value, value.slideExpire())
+}
+
+// slideExpire return the expire time after sliding from now, not later than the max expire time
+func (i *cacheItem) slideExpire() int64 {
+	expireUnixNanosecondDateTime := ExpireAfter(i.sliding)
+	if i.maxExpireUnixNanosecondDateTime != 0 && expireUnixNanosecondDateTime > i.maxExpireUnixNanosecondDateTime {
+		return i.maxExpireUnixNanosecondDateTime
+	}
+
+	return expireUnixNanosecondDateTime
+}
+
+// slide push the expire time of the live sliding item forward in O(log n), must hold the lock,
+// it is not appended to the append only file, a log write on every read cost too much
+func (c *cache) slide(heapValue *algorithm.HeapValue) {
+	item := heapValue.Extra.(*cacheItem)
+	if item.sliding <= 0 {
+		return
+	}
+
+	c.setExpire(heapValue, item.slideExpire())
+}
+
+func (c *shardedCache) SetSliding(key string, value []byte, slidingExpiration, maxLifetime time.Duration) {
+	c.shard(key).SetSliding(key, value, slidingExpiration, maxLifetime)
+}
+
+func (c *shardedCache) SetInterfaceSliding(key string, value interface{}, slidingExpiration, maxLifetime time.Duration) {
+	c.shard(key).SetInterfaceSliding(key, value, slidingExpiration, maxLifetime)
+}
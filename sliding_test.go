@@ -0,0 +1,102 @@
+package gocache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSliding(t *testing.T) {
+	c := NewWithOptions(WithJanitor(false)).(*cache)
+	defer c.ShutDown()
+
+	// the margins are hundreds of milliseconds, so a slow scheduler does not fail the test
+	c.SetSliding("session", []byte("a"), 600*time.Millisecond, 0)
+	c.Set("fixed", []byte("b"), 300*time.Millisecond)
+	_, expire, _ := c.Get("session")
+	for i := 0; i < 4; i++ {
+		time.Sleep(150 * time.Millisecond)
+		_, e, exist := c.Get("session")
+		if !exist || e <= expire {
+			t.Fatalf("get should push the expire time forward, but %d <= %d %v", e, expire, exist)
+		}
+		expire = e
+	}
+
+	if _, _, exist := c.Get("fixed"); exist {
+		t.Fatalf("not sliding key should expire")
+	}
+
+	// the heap is fixed after sliding
+	if key, _, _ := c.GetOldestKey(); key != "session" || c.minHeap.Min().Value != expire {
+		t.Fatalf("heap should have the new expire time, but %s %d", key, c.minHeap.Min().Value)
+	}
+
+	time.Sleep(time.Until(time.Unix(0, expire)) + 200*time.Millisecond)
+	c.cleanOlder()
+	if c.Size() != 0 {
+		t.Fatalf("sliding key should expire when not read, but %v", c.KeyList())
+	}
+
+	// max lifetime
+	c.SetInterfaceSliding("capped", 1, 600*time.Millisecond, time.Second)
+	maxExpire := c.lookup("capped").maxExpireUnixNanosecondDateTime
+	for i := 0; i < 4; i++ {
+		time.Sleep(150 * time.Millisecond)
+		if _, e, exist := c.GetInterface("capped"); !exist || e > maxExpire {
+			t.Fatalf("sliding should not exceed the max lifetime, but %d > %d %v", e, maxExpire, exist)
+		}
+	}
+
+	if _, e, _ := c.GetInterface("capped"); e != maxExpire {
+		t.Fatalf("sliding should stop at the max lifetime, but %d != %d", e, maxExpire)
+	}
+
+	time.Sleep(time.Until(time.Unix(0, maxExpire)) + 200*time.Millisecond)
+	if _, _, exist := c.GetInterface("capped"); exist {
+		t.Fatalf("sliding key should expire after the max lifetime")
+	}
+
+	// counters and compare and swap keep sliding, expire turn it to absolute
+	c.SetSliding("counter", []byte("1"), time.Minute, 0)
+	c.Incr("counter", 1, time.Second)
+	c.CompareAndSwap("counter", []byte("2"), []byte("3"))
+	if item := c.lookup("counter"); item.sliding != time.Minute {
+		t.Fatalf("counter should keep sliding, but %v", item.sliding)
+	}
+
+	c.Expire("counter", time.Hour)
+	_, expire, _ = c.Get("counter")
+	if _, e, _ := c.Get("counter"); e != expire || c.lookup("counter").sliding != 0 {
+		t.Fatalf("expire should stop sliding")
+	}
+
+	s := NewSharded(4)
+	defer s.ShutDown()
+	s.SetSliding("key", []byte("a"), time.Minute, time.Hour)
+	if ttl, _ := s.TTL("key"); ttl <= 59*time.Second || ttl > time.Minute {
+		t.Fatalf("sharded sliding key should expire after a minute, but %v", ttl)
+	}
+}
+
+// TestSlidingGet run with -race, the concurrent gets slide the expire time while the others read it
+func TestSlidingGet(t *testing.T) {
+	c := New()
+	defer c.ShutDown()
+
+	c.SetSliding("session", []byte("a"), time.Minute, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				if _, _, exist := c.Get("session"); !exist {
+					t.Errorf("session should exist")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,293 @@
+package gocache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// snapshot format:
+//
+//	magic "GOCACHE" | version byte | records... | end byte | crc32 of all the bytes before
+//	record: type byte | expireUnixNanosecondDateTime int64 | key length uvarint | key | value length uvarint | value
+const (
+	snapshotMagic   = "GOCACHE"
+	snapshotVersion = 1
+
+	recordTypeByte      byte = 1
+	recordTypeInterface byte = 2
+	recordTypeEnd       byte = 0xff
+
+	// avoid the corrupt length alloc too much memory
+	snapshotMaxFieldLength = 1 << 30
+)
+
+var (
+	ErrSnapshotFormat   = errors.New("gocache: snapshot format invalid")
+	ErrSnapshotVersion  = errors.New("gocache: snapshot version not support")
+	ErrSnapshotChecksum = errors.New("gocache: snapshot checksum mismatch")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type snapshotRecord struct {
+	key                          string
+	value                        []byte
+	raw                          interface{}
+	isInterface                  bool
+	expireUnixNanosecondDateTime int64
+}
+
+func (c *cache) SaveSnapshot(w io.Writer) error {
+	return writeSnapshot(w, c.opts.codec, c.snapshotRecords())
+}
+
+func (c *cache) LoadSnapshot(r io.Reader) error {
+	records, err := readSnapshot(r, c.opts.codec)
+	if err != nil {
+		return err
+	}
+
+	c.loadRecords(records)
+	return nil
+}
+
+// snapshotRecords return the not expired keys
+func (c *cache) snapshotRecords() []snapshotRecord {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return nil
+	}
+
+	return c.records()
+}
+
+// records return the not expired keys, must hold the lock
+func (c *cache) records() []snapshotRecord {
+	now := time.Now().UnixNano()
+	records := make([]snapshotRecord, 0, c.size())
+	for i := 0; i < c.size(); i++ {
+		h := c.at(i)
+		if h.Value <= now {
+			continue
+		}
+
+		item := h.Extra.(*cacheItem)
+		records = append(records, snapshotRecord{
+			key:                          h.Key,
+			value:                        item.RawByte,
+			raw:                          item.Raw,
+			isInterface:                  item.isInterface,
+			expireUnixNanosecondDateTime: item.expireUnixNanosecondDateTime,
+		})
+	}
+
+	return records
+}
+
+// loadRecords put the not expired records in one critical section
+func (c *cache) loadRecords(records []snapshotRecord) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	now := time.Now().UnixNano()
+	for _, record := range records {
+		if record.expireUnixNanosecondDateTime <= now {
+			continue
+		}
+
+		item := cacheItem{
+			RawByte: record.value,
+			cost:    int64(len(record.key) + len(record.value)),
+		}
+		if record.isInterface {
+			item = cacheItem{
+				Raw:         record.raw,
+				isInterface: true,
+				cost:        c.interfaceCost(record.key, record.raw),
+			}
+		}
+
+		c.put(record.key, item, record.expireUnixNanosecondDateTime)
+	}
+}
+
+func writeSnapshot(w io.Writer, codec Codec, records []snapshotRecord) error {
+	bw := bufio.NewWriter(w)
+	crc := crc32.New(crcTable)
+	mw := io.MultiWriter(bw, crc)
+
+	header := append([]byte(snapshotMagic), snapshotVersion)
+	if _, err := mw.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 0, 64)
+	for _, record := range records {
+		recordType := recordTypeByte
+		value := record.value
+		if record.isInterface {
+			recordType = recordTypeInterface
+			v, err := codec.Marshal(record.raw)
+			if err != nil {
+				return fmt.Errorf("gocache: marshal value of key %s: %w", record.key, err)
+			}
+			value = v
+		}
+
+		buf = append(buf[:0], recordType)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(record.expireUnixNanosecondDateTime))
+		buf = binary.AppendUvarint(buf, uint64(len(record.key)))
+		buf = append(buf, record.key...)
+		buf = binary.AppendUvarint(buf, uint64(len(value)))
+		if _, err := mw.Write(buf); err != nil {
+			return err
+		}
+
+		if _, err := mw.Write(value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := mw.Write([]byte{recordTypeEnd}); err != nil {
+		return err
+	}
+
+	if _, err := bw.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32())); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// snapshotReader hash the bytes when read
+type snapshotReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (r *snapshotReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.crc.Write(p[:n])
+	return n, err
+}
+
+func (r *snapshotReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+func (r *snapshotReader) readField() ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > snapshotMaxFieldLength {
+		return nil, ErrSnapshotFormat
+	}
+
+	field := make([]byte, n)
+	if _, err := io.ReadFull(r, field); err != nil {
+		return nil, err
+	}
+
+	return field, nil
+}
+
+// readSnapshot read all the records, return error if the checksum mismatch
+func readSnapshot(r io.Reader, codec Codec) (records []snapshotRecord, err error) {
+	sr := &snapshotReader{r: bufio.NewReader(r), crc: crc32.New(crcTable)}
+	defer func() {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = ErrSnapshotFormat
+		}
+	}()
+
+	header := make([]byte, len(snapshotMagic)+1)
+	if _, err = io.ReadFull(sr, header); err != nil {
+		return nil, err
+	}
+
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, ErrSnapshotFormat
+	}
+
+	if header[len(snapshotMagic)] != snapshotVersion {
+		return nil, ErrSnapshotVersion
+	}
+
+	for {
+		recordType, err := sr.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if recordType == recordTypeEnd {
+			break
+		}
+
+		if recordType != recordTypeByte && recordType != recordTypeInterface {
+			return nil, ErrSnapshotFormat
+		}
+
+		expire := make([]byte, 8)
+		if _, err = io.ReadFull(sr, expire); err != nil {
+			return nil, err
+		}
+
+		key, err := sr.readField()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := sr.readField()
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, snapshotRecord{
+			key:                          string(key),
+			value:                        value,
+			isInterface:                  recordType == recordTypeInterface,
+			expireUnixNanosecondDateTime: int64(binary.BigEndian.Uint64(expire)),
+		})
+	}
+
+	sum := sr.crc.Sum32()
+	checksum := make([]byte, 4)
+	if _, err = io.ReadFull(sr.r, checksum); err != nil {
+		return nil, err
+	}
+
+	if binary.BigEndian.Uint32(checksum) != sum {
+		return nil, ErrSnapshotChecksum
+	}
+
+	// decode after the checksum is verified
+	for i := range records {
+		if !records[i].isInterface {
+			continue
+		}
+
+		records[i].raw, err = codec.Unmarshal(records[i].value)
+		if err != nil {
+			return nil, fmt.Errorf("gocache: unmarshal value of key %s: %w", records[i].key, err)
+		}
+		records[i].value = nil
+	}
+
+	return records, nil
+}
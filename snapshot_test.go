@@ -0,0 +1,77 @@
+package gocache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+)
+
+func TestSaveSnapshot(t *testing.T) {
+	gob.Register(map[string]int{})
+
+	c := NewSharded(4)
+	defer c.ShutDown()
+
+	c.Set("a", []byte("a hi"), 10*time.Second)
+	c.SetInterface("b", 100, 10*time.Second)
+	c.SetInterface("c", map[string]int{"c": 1}, 10*time.Second)
+	c.SetInterface("nil", nil, 10*time.Second)
+	c.Set("d", []byte("expired"), 10*time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := c.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c2 := New()
+	defer c2.ShutDown()
+	if err := c2.LoadSnapshot(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if c2.Size() != 4 {
+		t.Fatalf("size should be 4, but %d", c2.Size())
+	}
+
+	_, expire, _ := c.Get("a")
+	v, expire2, _ := c2.Get("a")
+	if string(v) != "a hi" || expire != expire2 {
+		t.Fatalf("a should be a hi expire at %d, but %s %d", expire, v, expire2)
+	}
+
+	if b, _, _ := c2.GetInterface("b"); b != 100 {
+		t.Fatalf("b should be 100, but %v", b)
+	}
+
+	if m, _, _ := c2.GetInterface("c"); m.(map[string]int)["c"] != 1 {
+		t.Fatalf("c should be map[c:1], but %v", m)
+	}
+
+	// the nil interface is still an interface
+	if v, _, exist := c2.GetInterface("nil"); !exist || v != nil {
+		t.Fatalf("nil should be restored, but %v %v", v, exist)
+	}
+
+	for _, record := range c2.(*cache).snapshotRecords() {
+		if record.key == "nil" && !record.isInterface {
+			t.Fatalf("nil should be restored as an interface")
+		}
+	}
+
+	c3 := New()
+	defer c3.ShutDown()
+
+	// corrupt
+	data[len(data)-6] ^= 0xff
+	if err := c3.LoadSnapshot(bytes.NewReader(data)); err != ErrSnapshotChecksum {
+		t.Fatalf("load should return checksum error, but %v", err)
+	}
+
+	if err := c3.LoadSnapshot(bytes.NewReader(data[:10])); err != ErrSnapshotFormat {
+		t.Fatalf("load should return format error, but %v", err)
+	}
+}
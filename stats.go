@@ -0,0 +1,115 @@
+package gocache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// JanitorRunDurationBounds the upper bounds of the janitor run duration histogram buckets,
+// the last bucket of Stats.JanitorRunDurationBuckets count the runs longer than all bounds
+var JanitorRunDurationBounds = [...]time.Duration{
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+}
+
+// Stats the counters of cache since created or last ResetStats
+type Stats struct {
+	Hits               uint64 // Get or GetInterface found the key
+	Misses             uint64 // Get or GetInterface not found the key
+	Sets               uint64 // keys written
+	Deletes            uint64 // keys removed by Delete
+	LazyExpirations    uint64 // expired keys removed when get them
+	JanitorExpirations uint64 // expired keys removed by the background janitor
+	CapacityEvictions  uint64 // keys evicted because of the max entries or the max memory
+	Size               int    // current number of keys
+
+	JanitorRuns               uint64                                    // times the janitor run
+	JanitorRunDuration        time.Duration                             // total duration of the janitor runs
+	JanitorRunDurationBuckets [len(JanitorRunDurationBounds) + 1]uint64 // number of runs per JanitorRunDurationBounds bucket
+}
+
+type cacheStats struct {
+	hits               atomic.Uint64
+	misses             atomic.Uint64
+	sets               atomic.Uint64
+	deletes            atomic.Uint64
+	lazyExpirations    atomic.Uint64
+	janitorExpirations atomic.Uint64
+	capacityEvictions  atomic.Uint64
+	janitor            janitorStats
+}
+
+type janitorStats struct {
+	runs     atomic.Uint64
+	duration atomic.Int64
+	buckets  [len(JanitorRunDurationBounds) + 1]atomic.Uint64
+}
+
+func (s *janitorStats) observe(d time.Duration) {
+	s.runs.Add(1)
+	s.duration.Add(int64(d))
+
+	i := 0
+	for i < len(JanitorRunDurationBounds) && d > JanitorRunDurationBounds[i] {
+		i++
+	}
+	s.buckets[i].Add(1)
+}
+
+// addTo add the janitor counters to stats
+func (s *janitorStats) addTo(stats *Stats) {
+	stats.JanitorRuns += s.runs.Load()
+	stats.JanitorRunDuration += time.Duration(s.duration.Load())
+	for i := range s.buckets {
+		stats.JanitorRunDurationBuckets[i] += s.buckets[i].Load()
+	}
+}
+
+func (s *janitorStats) reset() {
+	s.runs.Store(0)
+	s.duration.Store(0)
+	for i := range s.buckets {
+		s.buckets[i].Store(0)
+	}
+}
+
+// addTo add the counters to stats
+func (s *cacheStats) addTo(stats *Stats) {
+	stats.Hits += s.hits.Load()
+	stats.Misses += s.misses.Load()
+	stats.Sets += s.sets.Load()
+	stats.Deletes += s.deletes.Load()
+	stats.LazyExpirations += s.lazyExpirations.Load()
+	stats.JanitorExpirations += s.janitorExpirations.Load()
+	stats.CapacityEvictions += s.capacityEvictions.Load()
+	s.janitor.addTo(stats)
+}
+
+func (s *cacheStats) reset() {
+	s.hits.Store(0)
+	s.misses.Store(0)
+	s.sets.Store(0)
+	s.deletes.Store(0)
+	s.lazyExpirations.Store(0)
+	s.janitorExpirations.Store(0)
+	s.capacityEvictions.Store(0)
+	s.janitor.reset()
+}
+
+func (c *cache) Stats() Stats {
+	var stats Stats
+	c.stats.addTo(&stats)
+	stats.Size = c.Size()
+	return stats
+}
+
+func (c *cache) ResetStats() {
+	c.stats.reset()
+}
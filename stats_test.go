@@ -0,0 +1,59 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	c := NewWithOptions(WithMaxEntries(3), WithCleanInterval(30*time.Millisecond))
+	defer c.ShutDown()
+
+	c.Set("a", []byte("a"), 10*time.Second)
+	c.Set("b", []byte("b"), time.Millisecond)
+	c.Set("c", []byte("c"), time.Millisecond)
+	c.Get("a")
+	c.Get("x")
+	time.Sleep(2 * time.Millisecond)
+	c.Get("b")
+	c.Delete("a")
+	c.Set("d", []byte("d"), 10*time.Second)
+	c.Set("e", []byte("e"), 10*time.Second)
+	c.Set("f", []byte("f"), 10*time.Second)
+	time.Sleep(100 * time.Millisecond)
+
+	stats := c.Stats()
+	expect := Stats{
+		Hits:               1,
+		Misses:             2,
+		Sets:               6,
+		Deletes:            1,
+		LazyExpirations:    1,
+		JanitorExpirations: 0,
+		CapacityEvictions:  1,
+		Size:               3,
+	}
+
+	if stats.JanitorRuns == 0 || stats.JanitorRunDuration == 0 {
+		t.Fatalf("janitor should run, but %+v", stats)
+	}
+
+	var runs uint64
+	for _, n := range stats.JanitorRunDurationBuckets {
+		runs += n
+	}
+
+	if runs != stats.JanitorRuns {
+		t.Fatalf("janitor run buckets should sum to %d, but %d", stats.JanitorRuns, runs)
+	}
+
+	stats.JanitorRuns, stats.JanitorRunDuration, stats.JanitorRunDurationBuckets = 0, 0, expect.JanitorRunDurationBuckets
+	if stats != expect {
+		t.Fatalf("stats should be %+v, but %+v", expect, stats)
+	}
+
+	c.ResetStats()
+	if stats = c.Stats(); stats.Hits != 0 || stats.Sets != 0 || stats.JanitorRuns != 0 || stats.Size != 3 {
+		t.Fatalf("stats should be reset, but %+v", stats)
+	}
+}
@@ -0,0 +1,89 @@
+package gocache
+
+import (
+	"github.com/hunterhug/gocache/algorithm"
+	"time"
+)
+
+// SetWithTags set the key with the tags, InvalidateTag delete all the keys with the tag,
+// set the key again without tags drop its tags, the tags are not kept in the snapshot and the append only file
+func (c *cache) SetWithTags(key string, value []byte, expireTime time.Duration, tags ...string) {
+	item := cacheItem{
+		RawByte: value,
+		cost:    int64(len(key) + len(value)),
+	}
+
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			item.tags = append(item.tags, tag)
+			item.cost += int64(len(tag))
+		}
+	}
+
+	c.set(key, item, expireTime)
+}
+
+// InvalidateTag delete all the keys with the tag in one critical section, return the number of deleted keys
+func (c *cache) InvalidateTag(tag string) int {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return 0
+	}
+
+	tagged := make([]*algorithm.HeapValue, 0, len(c.tags[tag]))
+	for key := range c.tags[tag] {
+		if treeMapValue, exist := c.treeMap.Get(key); exist {
+			tagged = append(tagged, treeMapValue.(*algorithm.HeapValue))
+		}
+	}
+
+	return c.deleteAll(tagged)
+}
+
+// addTags add the key into the reverse index of its tags, must hold the lock
+func (c *cache) addTags(key string, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	if c.tags == nil {
+		c.tags = make(map[string]map[string]struct{})
+	}
+
+	for _, tag := range tags {
+		keys := c.tags[tag]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// removeTags remove the key from the reverse index of its tags, the empty tags are dropped, must hold the lock
+func (c *cache) removeTags(key string, tags []string) {
+	for _, tag := range tags {
+		keys := c.tags[tag]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// InvalidateTag each shard delete its keys with the tag in its own critical section
+func (c *shardedCache) InvalidateTag(tag string) int {
+	deleted := 0
+	for _, s := range c.shards {
+		deleted += s.InvalidateTag(tag)
+	}
+
+	return deleted
+}
+
+func (c *shardedCache) SetWithTags(key string, value []byte, expireTime time.Duration, tags ...string) {
+	c.shard(key).SetWithTags(key, value, expireTime, tags...)
+}
@@ -0,0 +1,55 @@
+package gocache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTags(t *testing.T) {
+	c := NewWithOptions(WithJanitor(false)).(*cache)
+	defer c.ShutDown()
+
+	c.SetWithTags("user:1", []byte("a"), time.Minute, "row:users:1", "table:users", "table:users")
+	c.SetWithTags("profile:1", []byte("b"), time.Minute, "row:users:1")
+	c.SetWithTags("user:2", []byte("c"), time.Minute, "table:users")
+	c.Set("other", []byte("d"), time.Minute)
+
+	if n := c.InvalidateTag("row:users:1"); n != 2 || c.Size() != 2 {
+		t.Fatalf("should delete user:1 and profile:1, but %d, keys %v", n, c.KeyList())
+	}
+
+	if _, exist := c.tags["row:users:1"]; exist || len(c.tags["table:users"]) != 1 {
+		t.Fatalf("deleted keys should be removed from the index, but %v", c.tags)
+	}
+
+	// set again without tags
+	c.Set("user:2", []byte("c"), time.Minute)
+	if n := c.InvalidateTag("table:users"); n != 0 || len(c.tags) != 0 {
+		t.Fatalf("user:2 should have no tags, but %d %v", n, c.tags)
+	}
+
+	c.SetWithTags("delete", []byte("a"), time.Minute, "delete")
+	c.Delete("delete")
+	c.SetWithTags("lazy", []byte("a"), time.Millisecond, "lazy")
+	c.SetWithTags("janitor", []byte("a"), time.Millisecond, "janitor")
+	time.Sleep(2 * time.Millisecond)
+	c.Get("lazy")
+	if _, exist := c.tags["lazy"]; exist || len(c.tags) != 1 {
+		t.Fatalf("lazy expired key should be removed from the index, but %v", c.tags)
+	}
+
+	c.cleanOlder()
+	if len(c.tags) != 0 {
+		t.Fatalf("janitor expired key should be removed from the index, but %v", c.tags)
+	}
+
+	s := NewSharded(4)
+	defer s.ShutDown()
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		s.SetWithTags(key, []byte(key), time.Minute, "all")
+	}
+
+	if n := s.InvalidateTag("all"); n != 5 || s.Size() != 0 {
+		t.Fatalf("sharded should delete all the keys, but %d", n)
+	}
+}
@@ -0,0 +1,291 @@
+package gocache
+
+import (
+	"github.com/hunterhug/gocache/algorithm"
+	"sync"
+	"time"
+)
+
+// TypedCache type safe cache, keep the keys in an ordered map and clean the expired keys by min heap same as Cache,
+// only the janitor and heap options of NewWithOptions take effect
+type TypedCache[K comparable, V any] struct {
+	minHeap *algorithm.Heap
+	treeMap algorithm.OrderedMap[K, *algorithm.HeapValue]
+	opts    options
+	onEvict func(key K, value V, reason EvictReason)
+	// removed keys wait to call onEvict after unlock
+	evicted []typedItem[K, V]
+	close   bool
+	locker  sync.Mutex
+
+	// the keys never expire, kept outside the heap same as Cache
+	persistent []*algorithm.HeapValue
+}
+
+type typedItem[K comparable, V any] struct {
+	key                          K
+	value                        V
+	expireUnixNanosecondDateTime int64
+	reason                       EvictReason
+}
+
+// NewTypedCache new a type safe cache with ordered key
+func NewTypedCache[K algorithm.Ordered, V any](opts ...Option) *TypedCache[K, V] {
+	return newTypedCache[K, V](algorithm.NewOrderedMap[K, *algorithm.HeapValue](), opts...)
+}
+
+// NewTypedCacheFunc new a type safe cache, keys are compared by the compare func,
+// which return a negative number when key1 < key2, a positive number when key1 > key2, zero when equal
+func NewTypedCacheFunc[K comparable, V any](compare func(key1, key2 K) int, opts ...Option) *TypedCache[K, V] {
+	return newTypedCache[K, V](algorithm.NewOrderedMapFunc[K, *algorithm.HeapValue](compare), opts...)
+}
+
+func newTypedCache[K comparable, V any](treeMap algorithm.OrderedMap[K, *algorithm.HeapValue], opts ...Option) *TypedCache[K, V] {
+	c := new(TypedCache[K, V])
+	c.opts = newOptions(opts...)
+	c.treeMap = treeMap
+	c.minHeap = algorithm.NewMinHeapWithCap(c.opts.heapInitCap)
+
+	if c.opts.janitor {
+		go c.loopCleanExpireItem()
+	}
+	return c
+}
+
+func (c *TypedCache[K, V]) loopCleanExpireItem() {
+	timer := time.NewTimer(c.opts.cleanInterval)
+	for {
+		select {
+		case <-timer.C:
+			if c.cleanOlder() {
+				timer.Stop()
+				return
+			}
+			timer.Reset(c.opts.cleanInterval)
+		}
+	}
+}
+
+// cleanOlder remove the expired keys, return true if the cache is closed
+func (c *TypedCache[K, V]) cleanOlder() (closed bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return true
+	}
+
+	now := time.Now().UnixNano()
+	for i := 0; i < c.opts.cleanMaxRemovals; i++ {
+		min := c.minHeap.Min()
+		if min == nil || min.Value > now {
+			return
+		}
+
+		c.remove(min, EvictReasonExpired)
+	}
+
+	return
+}
+
+// track put the heap value into the heap, or into the persistent list when it never expire, must hold the lock
+func (c *TypedCache[K, V]) track(heapValue *algorithm.HeapValue) {
+	if heapValue.Value != NeverExpire {
+		c.minHeap.Push(heapValue)
+		return
+	}
+
+	heapValue.Index = len(c.persistent)
+	c.persistent = append(c.persistent, heapValue)
+}
+
+// untrack remove the heap value from the heap or the persistent list, must hold the lock
+func (c *TypedCache[K, V]) untrack(heapValue *algorithm.HeapValue) {
+	if heapValue.Value != NeverExpire {
+		c.minHeap.PopIndex(heapValue.Index)
+		return
+	}
+
+	last := len(c.persistent) - 1
+	c.persistent[heapValue.Index] = c.persistent[last]
+	c.persistent[heapValue.Index].Index = heapValue.Index
+	c.persistent[last] = nil
+	c.persistent = c.persistent[:last]
+}
+
+// remove the key from heap and ordered map, must hold the lock
+func (c *TypedCache[K, V]) remove(heapValue *algorithm.HeapValue, reason EvictReason) {
+	c.untrack(heapValue)
+	item := heapValue.Extra.(*typedItem[K, V])
+	c.treeMap.Delete(item.key)
+	c.addEvicted(item, reason)
+}
+
+func (c *TypedCache[K, V]) addEvicted(item *typedItem[K, V], reason EvictReason) {
+	if c.onEvict == nil {
+		return
+	}
+
+	evicted := *item
+	evicted.reason = reason
+	c.evicted = append(c.evicted, evicted)
+}
+
+// unlock release the lock, then call the evict func outside the lock
+func (c *TypedCache[K, V]) unlock() {
+	evicted := c.evicted
+	onEvict := c.onEvict
+	c.evicted = nil
+	c.locker.Unlock()
+
+	for _, e := range evicted {
+		onEvict(e.key, e.value, e.reason)
+	}
+}
+
+// OnEvict set the func called when the key is removed from cache
+func (c *TypedCache[K, V]) OnEvict(f func(key K, value V, reason EvictReason)) {
+	c.locker.Lock()
+	defer c.unlock()
+	c.onEvict = f
+}
+
+func (c *TypedCache[K, V]) ShutDown() {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	for i := 0; i < c.minHeap.Size(); i++ {
+		c.addEvicted(c.minHeap.Get(i).Extra.(*typedItem[K, V]), EvictReasonShutDown)
+	}
+
+	for _, heapValue := range c.persistent {
+		c.addEvicted(heapValue.Extra.(*typedItem[K, V]), EvictReasonShutDown)
+	}
+
+	c.close = true
+}
+
+func (c *TypedCache[K, V]) Set(key K, value V, expireTime time.Duration) {
+	c.SetByExpireUnixNanosecondDateTime(key, value, ExpireAfter(expireTime))
+}
+
+func (c *TypedCache[K, V]) SetByExpireUnixNanosecondDateTime(key K, value V, expireUnixNanosecondDateTime int64) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	item := &typedItem[K, V]{
+		key:                          key,
+		value:                        value,
+		expireUnixNanosecondDateTime: expireUnixNanosecondDateTime,
+	}
+
+	old, exist := c.treeMap.Get(key)
+	if !exist {
+		innerValue := &algorithm.HeapValue{
+			Value: expireUnixNanosecondDateTime,
+			Extra: item,
+		}
+		c.treeMap.Put(key, innerValue)
+		c.track(innerValue)
+		return
+	}
+
+	c.untrack(old)
+	c.addEvicted(old.Extra.(*typedItem[K, V]), EvictReasonReplaced)
+	old.Value = expireUnixNanosecondDateTime
+	old.Extra = item
+	c.track(old)
+}
+
+func (c *TypedCache[K, V]) Delete(key K) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	heapValue, exist := c.treeMap.Get(key)
+	if !exist {
+		return
+	}
+
+	c.remove(heapValue, EvictReasonDeleted)
+}
+
+func (c *TypedCache[K, V]) Get(key K) (value V, expireUnixNanosecondDateTime int64, exist bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	heapValue, exist := c.treeMap.Get(key)
+	if !exist {
+		return
+	}
+
+	item := heapValue.Extra.(*typedItem[K, V])
+	if item.expireUnixNanosecondDateTime <= time.Now().UnixNano() {
+		c.remove(heapValue, EvictReasonExpired)
+		return value, 0, false
+	}
+
+	return item.value, item.expireUnixNanosecondDateTime, true
+}
+
+// Range call f for each not expired key in key order, stop when f return false,
+// f is called outside the lock on a snapshot of the keys, so it can call back into the cache
+func (c *TypedCache[K, V]) Range(f func(key K, value V, expireUnixNanosecondDateTime int64) bool) {
+	c.locker.Lock()
+	if c.close {
+		c.locker.Unlock()
+		return
+	}
+
+	now := time.Now().UnixNano()
+	items := make([]*typedItem[K, V], 0, c.treeMap.Len())
+	c.treeMap.Range(func(key K, heapValue *algorithm.HeapValue) bool {
+		item := heapValue.Extra.(*typedItem[K, V])
+		if item.expireUnixNanosecondDateTime > now {
+			items = append(items, item)
+		}
+		return true
+	})
+	c.locker.Unlock()
+
+	for _, item := range items {
+		if !f(item.key, item.value, item.expireUnixNanosecondDateTime) {
+			return
+		}
+	}
+}
+
+func (c *TypedCache[K, V]) GetOldestKey() (key K, expireUnixNanosecondDateTime int64, exist bool) {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return
+	}
+
+	min := c.minHeap.Min()
+	if min != nil {
+		return min.Extra.(*typedItem[K, V]).key, min.Value, true
+	}
+
+	return
+}
+
+func (c *TypedCache[K, V]) Size() int {
+	c.locker.Lock()
+	defer c.unlock()
+	if c.close {
+		return 0
+	}
+
+	return c.minHeap.Size() + len(c.persistent)
+}
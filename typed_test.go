@@ -0,0 +1,107 @@
+package gocache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+type point struct {
+	x, y int
+}
+
+func TestNewTypedCache(t *testing.T) {
+	c := NewTypedCache[int, point]()
+
+	var evicted []string
+	c.OnEvict(func(key int, value point, reason EvictReason) {
+		evicted = append(evicted, fmt.Sprintf("%d:%s", key, reason))
+	})
+
+	c.Set(3, point{3, 3}, 10*time.Second)
+	c.Set(1, point{1, 1}, 5*time.Second)
+	c.Set(2, point{2, 2}, time.Millisecond)
+	c.Set(3, point{30, 30}, 10*time.Second)
+
+	if v, _, exist := c.Get(3); !exist || v.x != 30 {
+		t.Fatalf("3 should be {30 30}, but %v", v)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	var keys []int
+	c.Range(func(key int, value point, expireUnixNanosecondDateTime int64) bool {
+		keys = append(keys, key)
+		return true
+	})
+
+	if fmt.Sprint(keys) != "[1 3]" {
+		t.Fatalf("range should be [1 3], but %v", keys)
+	}
+
+	if _, _, exist := c.Get(2); exist {
+		t.Fatal("2 should be expired")
+	}
+
+	if k, _, _ := c.GetOldestKey(); k != 1 {
+		t.Fatalf("oldest key should be 1, but %d", k)
+	}
+
+	c.Delete(1)
+	c.ShutDown()
+
+	if fmt.Sprint(evicted) != "[3:replaced 2:expired 1:deleted 3:shutdown]" {
+		t.Fatalf("evicted is not right: %v", evicted)
+	}
+}
+
+func TestTypedCacheNoExpiration(t *testing.T) {
+	c := NewTypedCache[int, point]()
+	defer c.ShutDown()
+
+	c.Set(1, point{1, 1}, NoExpiration)
+	c.Set(2, point{2, 2}, time.Minute)
+	if c.minHeap.Size() != 1 || len(c.persistent) != 1 || c.Size() != 2 {
+		t.Fatalf("never expire key should be kept outside the heap, but heap %d persistent %d", c.minHeap.Size(), len(c.persistent))
+	}
+
+	if _, expire, exist := c.Get(1); !exist || expire != NeverExpire {
+		t.Fatalf("1 should never expire, but %d %v", expire, exist)
+	}
+
+	if k, _, _ := c.GetOldestKey(); k != 2 {
+		t.Fatalf("oldest key should be 2, but %d", k)
+	}
+
+	c.Set(1, point{1, 1}, time.Second)
+	c.Set(2, point{2, 2}, NoExpiration)
+	if c.minHeap.Size() != 1 || len(c.persistent) != 1 {
+		t.Fatalf("replaced keys should move between the heap and persistent list, but heap %d persistent %d", c.minHeap.Size(), len(c.persistent))
+	}
+
+	c.Delete(2)
+	if _, _, exist := c.Get(2); exist || c.Size() != 1 || len(c.persistent) != 0 {
+		t.Fatalf("2 should be deleted, but size %d", c.Size())
+	}
+}
+
+func TestNewTypedCacheFunc(t *testing.T) {
+	c := NewTypedCacheFunc[point, string](func(key1, key2 point) int {
+		if key1.x != key2.x {
+			return key1.x - key2.x
+		}
+		return key1.y - key2.y
+	})
+	defer c.ShutDown()
+
+	c.Set(point{1, 2}, "b", time.Second)
+	c.Set(point{1, 1}, "a", time.Second)
+
+	if v, _, _ := c.Get(point{1, 1}); v != "a" {
+		t.Fatalf("{1 1} should be a, but %s", v)
+	}
+
+	if c.Size() != 2 {
+		t.Fatalf("size should be 2, but %d", c.Size())
+	}
+}